@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/manish-npx/simple-go-echo/internal/config"
+	"github.com/manish-npx/simple-go-echo/internal/database"
+	"github.com/manish-npx/simple-go-echo/internal/http/handlers"
+	"github.com/manish-npx/simple-go-echo/internal/logger"
+	"github.com/manish-npx/simple-go-echo/internal/server"
+	"github.com/manish-npx/simple-go-echo/internal/storage"
+)
+
+// checkTimeout bounds the database work of -check, and checkPingTimeout
+// the first connection to the main database within it.
+const (
+	checkTimeout     = 30 * time.Second
+	checkPingTimeout = 5 * time.Second
+)
+
+// runCheck validates what the server needs without serving anything: the
+// database connection, the schema (and those of every tenant database)
+// and the routes. The config was already loaded by the caller. Each step is
+// logged and the result is the process exit code. An unreachable main
+// database fails the database step and skips the schema step.
+func runCheck(cfg *config.Config, log logger.Logger) int {
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+
+	failed := false
+	step := func(name string, err error) {
+		if err != nil {
+			log.Error("check failed", "step", name, "error", err)
+			failed = true
+			return
+		}
+		log.Info("check passed", "step", name)
+	}
+
+	breaker := database.NewBreaker(cfg.Database.BreakerThreshold, cfg.Database.BreakerCooldown)
+	db, err := database.OpenPostgres(cfg, breaker, nil, log)
+	if err != nil {
+		step("database", err)
+		return 1
+	}
+	defer db.Close()
+	pingCtx, cancelPing := context.WithTimeout(ctx, checkPingTimeout)
+	err = db.Ping(pingCtx)
+	cancelPing()
+	step("database", err)
+
+	todos := storage.NewTodoStorage(db, log, storage.Options{})
+	if err == nil {
+		notify := cfg.API.ListCacheTTL > 0 && cfg.API.ListCacheNotify
+		step("schema", todos.CheckSchema(ctx, notify))
+	}
+
+	if cfg.Tenancy.Enabled {
+		tenants, err := database.NewTenantPools(cfg, nil, log)
+		step("tenancy", err)
+		if err == nil {
+			defer tenants.Close()
+			for _, name := range tenants.Names() {
+				step("tenant "+name, checkTenant(ctx, tenants, todos, name))
+			}
+		}
+	}
+
+	_, err = server.NewServer(cfg, db, nil, breaker, nil, log, handlers.BuildInfo{})
+	step("routes", err)
+
+	if failed {
+		return 1
+	}
+	log.Info("all checks passed")
+	return 0
+}
+
+// checkTenant connects to one tenant database and checks its schema.
+func checkTenant(ctx context.Context, tenants *database.TenantPools, todos *storage.TodoStorage, name string) error {
+	lease, err := tenants.Acquire(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer lease.Release()
+	if err := lease.Pool.Ping(ctx); err != nil {
+		return err
+	}
+	return todos.CheckSchema(database.WithTenant(ctx, name, lease), false)
+}
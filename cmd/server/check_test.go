@@ -0,0 +1,56 @@
+package main
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/manish-npx/simple-go-echo/internal/config"
+	"github.com/manish-npx/simple-go-echo/internal/logger"
+)
+
+// stepLogger records the steps runCheck reports as passed and failed.
+type stepLogger struct {
+	mu             sync.Mutex
+	passed, failed []string
+}
+
+func (l *stepLogger) record(msg string, args []any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := 0; i+1 < len(args); i += 2 {
+		if args[i] != "step" {
+			continue
+		}
+		switch msg {
+		case "check passed":
+			l.passed = append(l.passed, args[i+1].(string))
+		case "check failed":
+			l.failed = append(l.failed, args[i+1].(string))
+		}
+	}
+}
+
+func (l *stepLogger) Debug(msg string, args ...any) {}
+func (l *stepLogger) Info(msg string, args ...any)  { l.record(msg, args) }
+func (l *stepLogger) Warn(msg string, args ...any)  {}
+func (l *stepLogger) Error(msg string, args ...any) { l.record(msg, args) }
+func (l *stepLogger) With(args ...any) logger.Logger {
+	return l
+}
+
+func TestRunCheckReportsUnreachableDatabase(t *testing.T) {
+	// Nothing listens on port 1, so connecting fails straight away.
+	cfg := &config.Config{Database: config.Database{URL: "postgres://test@127.0.0.1:1/test?connect_timeout=1"}}
+	log := &stepLogger{}
+
+	if code := runCheck(cfg, log); code != 1 {
+		t.Errorf("exit code = %d, want 1", code)
+	}
+	if !slices.Equal(log.failed, []string{"database"}) {
+		t.Errorf("failed steps = %v, want [database]", log.failed)
+	}
+	if slices.Contains(log.passed, "database") || slices.Contains(log.passed, "schema") {
+		t.Errorf("passed steps = %v, want neither database nor schema", log.passed)
+	}
+}
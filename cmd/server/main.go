@@ -1,28 +1,174 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"os/signal"
+	"runtime"
+	"runtime/debug"
+	"syscall"
+	"time"
 
 	"github.com/manish-npx/simple-go-echo/internal/config"
 	"github.com/manish-npx/simple-go-echo/internal/database"
+	"github.com/manish-npx/simple-go-echo/internal/http/handlers"
+	"github.com/manish-npx/simple-go-echo/internal/jobs"
+	"github.com/manish-npx/simple-go-echo/internal/logger"
 	"github.com/manish-npx/simple-go-echo/internal/server"
 )
 
+// jobShutdownTimeout bounds how long shutdown waits for background jobs.
+const jobShutdownTimeout = 10 * time.Second
+
+// Set at build time:
+//
+//	go build -ldflags "-X main.commit=$(git rev-parse HEAD) -X main.buildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/server
+var (
+	commit    = ""
+	buildTime = ""
+)
+
 func main() {
-	log.Println("🚀 Starting application...")
+	check := flag.Bool("check", false, "validate config, database, schema and routes, then exit 0 or 1 without serving")
+	flag.Parse()
 
 	// Load configuration
 	cfg := config.LoadConfig()
 
+	log := logger.New(cfg.Log.Level, cfg.Log.Format)
+	build := buildInfo()
+	log.Info("starting application", "commit", build.Commit, "build_time", build.BuildTime, "go_version", build.GoVersion)
+	log.Info("config loaded", "files", cfg.Files, "env", cfg.Env)
+
+	if *check {
+		os.Exit(runCheck(cfg, log))
+	}
+
 	// Setup database
-	db := database.NewPostgres(cfg)
+	breaker := database.NewBreaker(cfg.Database.BreakerThreshold, cfg.Database.BreakerCooldown)
+	queryStats := database.NewQueryStats(cfg.Database.TopSlowQueries)
+	db := database.NewPostgres(cfg, breaker, queryStats, log)
 	defer db.Close()
 
+	var tenants *database.TenantPools
+	if cfg.Tenancy.Enabled {
+		var err error
+		tenants, err = database.NewTenantPools(cfg, queryStats, log)
+		if err != nil {
+			log.Error("invalid tenancy config", "error", err)
+			os.Exit(1)
+		}
+		defer tenants.Close()
+	}
+
 	// Create and start server / routes
-	srv := server.NewServer(cfg, db)
+	srv, err := server.NewServer(cfg, db, tenants, breaker, queryStats, log, build)
+	if err != nil {
+		log.Error("invalid routes", "error", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Background work; stopped after the HTTP server and before the pool
+	// closes, so no job loses its connection mid-transaction.
+	bg := jobs.NewGroup(log)
+	bg.Go("config-reload", func(ctx context.Context) { reloadOnSIGHUP(ctx, cfg, log) })
+	srv.StartJobs(bg)
+	if interval := cfg.Database.PoolStatsInterval; interval > 0 {
+		bg.Go("pool-stats", func(ctx context.Context) {
+			database.LogPoolStats(ctx, db, tenants, log, interval, cfg.Database.PoolStatsLevel)
+		})
+	}
 
-	log.Println("🚀 Server running on:", cfg.Server.Addr)
-	if err := srv.Start(); err != nil {
-		log.Fatal("Failed to start server:", err)
+	go func() {
+		log.Info("server running", "addr", cfg.Server.Addr, "env", cfg.Env)
+		if err := srv.Start(); err != nil {
+			log.Error("failed to start server", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Info("shutdown signal received, draining")
+
+	if err := srv.Shutdown(); err != nil {
+		log.Error("graceful shutdown failed", "error", err)
+		if errors.Is(err, server.ErrHandlersStuck) {
+			// Skip the deferred pool close: it would wait forever for the
+			// connections those handlers hold.
+			os.Exit(1)
+		}
+	}
+	if err := bg.Shutdown(jobShutdownTimeout); err != nil {
+		log.Error("background jobs did not stop", "error", err)
+	}
+	log.Info("server stopped")
+}
+
+// reloadOnSIGHUP re-reads the config file on every SIGHUP. Only log.level
+// is applied live; any other change is logged as needing a restart. An
+// invalid file is reported and the running config is kept.
+func reloadOnSIGHUP(ctx context.Context, cfg *config.Config, log logger.Logger) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	current := *cfg
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+		}
+
+		next, err := config.Load(config.Path)
+		if err != nil {
+			log.Error("config reload failed, keeping current config", "error", err)
+			continue
+		}
+
+		for _, key := range config.Changed(&current, next) {
+			switch key {
+			case "log.level":
+				logger.SetLevel(log, next.Log.Level)
+				log.Info("config reloaded", "setting", key, "from", current.Log.Level, "to", next.Log.Level)
+				current.Log.Level = next.Log.Level
+			default:
+				log.Info("config change requires restart", "setting", key)
+			}
+		}
+	}
+}
+
+// buildInfo falls back to the VCS stamp the go tool embeds when the binary
+// was built without -ldflags.
+func buildInfo() handlers.BuildInfo {
+	info := handlers.BuildInfo{
+		Commit:    commit,
+		BuildTime: buildTime,
+		GoVersion: runtime.Version(),
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && info.BuildTime == "":
+				info.BuildTime = s.Value
+			}
+		}
+	}
+
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildTime == "" {
+		info.BuildTime = "unknown"
 	}
+	return info
 }
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// load writes yaml to a config file and loads it, with APP_ENV and
+// DATABASE_URL cleared so the environment can't interfere.
+func load(t *testing.T, yaml string) (*Config, error) {
+	t.Helper()
+	t.Setenv("APP_ENV", "")
+	t.Setenv("DATABASE_URL", "")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return Load(path)
+}
+
+// validDigest is the SHA-256 of "test".
+const validDigest = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+func TestLoadValidatesAPIKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    string
+		wantErr string
+	}{
+		{"valid", "- label: billing\n    sha256: " + validDigest, ""},
+		{"valid with spaces", "- label: billing\n    sha256: '  " + validDigest + " '", ""},
+		{"bad hex", "- label: billing\n    sha256: " + strings.Repeat("zz", 32), "auth.api_keys billing: sha256 must be 64 hex characters"},
+		{"short", "- label: billing\n    sha256: " + validDigest[:62], "auth.api_keys billing: sha256 must be 64 hex characters"},
+		{"no label", "- sha256: " + validDigest, "auth.api_keys[0]: label is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := load(t, "auth:\n  api_keys:\n  "+tt.keys+"\n")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(cfg.Auth.APIKeys) != 1 {
+					t.Errorf("keys = %v, want one", cfg.Auth.APIKeys)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadValidatesIDType(t *testing.T) {
+	for _, v := range []string{"int", "uuid"} {
+		cfg, err := load(t, "api:\n  id_type: "+v+"\n")
+		if err != nil {
+			t.Fatalf("id_type %s: %v", v, err)
+		}
+		if cfg.API.IDType != v {
+			t.Errorf("IDType = %q, want %q", cfg.API.IDType, v)
+		}
+	}
+	if _, err := load(t, "api:\n  id_type: ulid\n"); err == nil || err.Error() != `api.id_type "ulid" must be int or uuid` {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestLoadValidatesLengthLimits(t *testing.T) {
+	tests := []struct {
+		yaml    string
+		wantErr string
+	}{
+		{"api:\n  title_max_length: 0\n", ""},
+		{"api:\n  title_max_length: 100\n  description_max_length: 500\n", ""},
+		{"api:\n  title_max_length: 256\n", "api.title_max_length must be between 1 and 255, or 0 for the default"},
+		{"api:\n  title_max_length: -1\n", "api.title_max_length must be between 1 and 255, or 0 for the default"},
+		{"api:\n  description_max_length: 2001\n", "api.description_max_length must be between 1 and 2000, or 0 for the default"},
+	}
+	for _, tt := range tests {
+		_, err := load(t, tt.yaml)
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("%q: %v", tt.yaml, err)
+		}
+		if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+			t.Errorf("%q: err = %v, want %q", tt.yaml, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := &Config{
+		Database: Database{Password: "secret", URL: "postgres://app:secret@db:5432/todo?sslmode=disable"},
+		Tenancy: Tenancy{Tenants: map[string]string{
+			"acme":  "postgres://acme:hunter2@db/acme",
+			"local": "postgres://db/local",
+		}},
+		Auth: Auth{APIKeys: []APIKey{{Label: "ci", SHA256: "abc123"}}},
+	}
+
+	out := cfg.Redacted()
+	want := map[string]string{
+		"password":     "****",
+		"url":          "postgres://app:****@db:5432/todo?sslmode=disable",
+		"tenant acme":  "postgres://acme:****@db/acme",
+		"tenant local": "postgres://db/local",
+		"api key":      "****",
+	}
+	got := map[string]string{
+		"password":     out.Database.Password,
+		"url":          out.Database.URL,
+		"tenant acme":  out.Tenancy.Tenants["acme"],
+		"tenant local": out.Tenancy.Tenants["local"],
+		"api key":      out.Auth.APIKeys[0].SHA256,
+	}
+	for k, w := range want {
+		if got[k] != w {
+			t.Errorf("%s = %q, want %q", k, got[k], w)
+		}
+	}
+	if cfg.Database.URL != "postgres://app:secret@db:5432/todo?sslmode=disable" || cfg.Tenancy.Tenants["acme"] != "postgres://acme:hunter2@db/acme" {
+		t.Error("Redacted modified the original config")
+	}
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/manish-npx/simple-go-echo/internal/logger"
+)
+
+// LogPoolStats logs the pool's statistics, and those of every open tenant
+// pool (tenants may be nil), every interval until ctx is done. A count of
+// acquired connections that keeps climbing while traffic is flat points to
+// a leak. level is debug, info, warn or error.
+func LogPoolStats(ctx context.Context, pool *pgxpool.Pool, tenants *TenantPools, log logger.Logger, interval time.Duration, level string) {
+	logAt := logFunc(log, level)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		logAt("database pool stats", poolStatAttrs(pool.Stat())...)
+		if tenants != nil {
+			tenants.each(func(name string, p *pgxpool.Pool) {
+				logAt("database pool stats", append([]any{"tenant", name}, poolStatAttrs(p.Stat())...)...)
+			})
+		}
+	}
+}
+
+func poolStatAttrs(st *pgxpool.Stat) []any {
+	return []any{
+		"acquired", st.AcquiredConns(),
+		"idle", st.IdleConns(),
+		"total", st.TotalConns(),
+		"max", st.MaxConns(),
+		"acquire_count", st.AcquireCount(),
+		"acquire_duration", st.AcquireDuration(),
+		"empty_acquire_count", st.EmptyAcquireCount(),
+		"canceled_acquire_count", st.CanceledAcquireCount(),
+	}
+}
+
+func logFunc(log logger.Logger, level string) func(msg string, args ...any) {
+	switch level {
+	case "debug":
+		return log.Debug
+	case "warn":
+		return log.Warn
+	case "error":
+		return log.Error
+	default:
+		return log.Info
+	}
+}
@@ -2,32 +2,66 @@ package database
 
 import (
 	"context"
-	"fmt"
-	"log"
+	"os"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/multitracer"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/manish-npx/simple-go-echo/internal/config"
+	"github.com/manish-npx/simple-go-echo/internal/logger"
 )
 
-func NewPostgres(cfg *config.Config) *pgxpool.Pool {
-	dsn := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s",
-		cfg.Database.User,
-		cfg.Database.Password,
-		cfg.Database.Host,
-		cfg.Database.Port,
-		cfg.Database.DBName,
-		cfg.Database.SSLMode,
-	)
-
-	pool, err := pgxpool.New(context.Background(), dsn)
+// NewPostgres connects to the main database and exits the process if it
+// can't. stats may be nil.
+func NewPostgres(cfg *config.Config, breaker *Breaker, stats *QueryStats, log logger.Logger) *pgxpool.Pool {
+	pool, err := OpenPostgres(cfg, breaker, stats, log)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		log.Error("invalid database config", "error", err)
+		os.Exit(1)
 	}
 
 	if err := pool.Ping(context.Background()); err != nil {
-		log.Fatalf("Failed to ping database: %v", err)
+		log.Error("failed to ping database", "error", err)
+		os.Exit(1)
 	}
 
-	log.Println("✅ Connected to PostgreSQL successfully")
+	log.Info("connected to PostgreSQL",
+		"host", cfg.Database.Host,
+		"port", cfg.Database.Port,
+		"dbname", cfg.Database.DBName,
+	)
 	return pool
 }
+
+// OpenPostgres creates the main database's pool without connecting, so
+// callers can report an unreachable database themselves; the first query or
+// Ping connects. It only fails on an invalid config. stats may be nil.
+func OpenPostgres(cfg *config.Config, breaker *Breaker, stats *QueryStats, log logger.Logger) (*pgxpool.Pool, error) {
+	poolCfg, err := poolConfig(cfg.Database.DSN(), cfg.Database, breaker, stats, log)
+	if err != nil {
+		return nil, err
+	}
+	return pgxpool.NewWithConfig(context.Background(), poolCfg)
+}
+
+// poolConfig parses dsn and attaches the tracers db asks for, so every pool
+// (including tenant pools) feeds the breaker, the query logs and stats.
+func poolConfig(dsn string, db config.Database, breaker *Breaker, stats *QueryStats, log logger.Logger) (*pgxpool.Config, error) {
+	poolCfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	tracers := []pgx.QueryTracer{&breakerTracer{breaker: breaker}}
+	if db.LogQueries {
+		tracers = append(tracers, newQueryTracer(log, db.LogQueryArgs))
+	}
+	if t := db.SlowQueryThreshold; t > 0 {
+		tracers = append(tracers, &slowQueryTracer{log: log, threshold: t})
+	}
+	if stats != nil {
+		tracers = append(tracers, &queryStatsTracer{stats: stats})
+	}
+	poolCfg.ConnConfig.Tracer = multitracer.New(tracers...)
+	return poolCfg, nil
+}
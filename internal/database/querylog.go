@@ -0,0 +1,48 @@
+package database
+
+import (
+	"context"
+	"sort"
+
+	"github.com/jackc/pgx/v5/tracelog"
+	"github.com/manish-npx/simple-go-echo/internal/logger"
+)
+
+// newQueryTracer logs every statement and its duration through the app
+// logger at debug level. Query arguments are dropped unless withArgs is set,
+// since they may hold user data.
+func newQueryTracer(log logger.Logger, withArgs bool) *tracelog.TraceLog {
+	return &tracelog.TraceLog{
+		Logger:   queryLogger(log, withArgs),
+		LogLevel: tracelog.LogLevelDebug,
+	}
+}
+
+func queryLogger(log logger.Logger, withArgs bool) tracelog.Logger {
+	return tracelog.LoggerFunc(func(_ context.Context, level tracelog.LogLevel, msg string, data map[string]any) {
+		// Pool bookkeeping would drown out the statements we care about.
+		if (msg == "Acquire" || msg == "Release") && level > tracelog.LogLevelError {
+			return
+		}
+		if !withArgs {
+			delete(data, "args")
+		}
+
+		keys := make([]string, 0, len(data))
+		for k := range data {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		args := make([]any, 0, len(data)*2)
+		for _, k := range keys {
+			args = append(args, k, data[k])
+		}
+
+		if level <= tracelog.LogLevelError {
+			log.Error("pgx: "+msg, args...)
+			return
+		}
+		log.Debug("pgx: "+msg, args...)
+	})
+}
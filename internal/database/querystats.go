@@ -0,0 +1,152 @@
+package database
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// maxTrackedQueries bounds memory when statements are built dynamically.
+// Once full, a new statement only gets in by being slower than the
+// fastest one tracked, which it then replaces.
+const maxTrackedQueries = 1000
+
+// stringLiteral matches SQL string literals, which fingerprint drops so
+// statements differing only in inlined values are grouped.
+var stringLiteral = regexp.MustCompile(`'(?:[^']|'')*'`)
+
+// QueryStat summarises every run of one statement.
+type QueryStat struct {
+	Query string  `json:"query"`
+	Count int64   `json:"count"`
+	MaxMS float64 `json:"max_ms"`
+	AvgMS float64 `json:"avg_ms"`
+}
+
+type queryTotals struct {
+	count int64
+	total time.Duration
+	max   time.Duration
+}
+
+// QueryStats keeps the duration of every statement by fingerprint, for a
+// running "slowest queries" view that is cheaper than logging them all. It
+// is safe for concurrent use.
+type QueryStats struct {
+	top int
+
+	mu      sync.Mutex
+	queries map[string]*queryTotals
+}
+
+// NewQueryStats returns a tracker reporting the top slowest statements,
+// or nil if top is not positive.
+func NewQueryStats(top int) *QueryStats {
+	if top <= 0 {
+		return nil
+	}
+	return &QueryStats{top: top, queries: map[string]*queryTotals{}}
+}
+
+func (s *QueryStats) record(sql string, d time.Duration) {
+	key := fingerprint(sql)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q, ok := s.queries[key]
+	if !ok {
+		if len(s.queries) >= maxTrackedQueries && !s.evictFasterLocked(d) {
+			return
+		}
+		q = &queryTotals{}
+		s.queries[key] = q
+	}
+	q.count++
+	q.total += d
+	q.max = max(q.max, d)
+}
+
+// evictFasterLocked drops the tracked statement with the lowest max if it
+// is faster than d, and reports whether it did.
+func (s *QueryStats) evictFasterLocked(d time.Duration) bool {
+	var (
+		fastest string
+		lowest  time.Duration
+	)
+	for key, q := range s.queries {
+		if fastest == "" || q.max < lowest {
+			fastest, lowest = key, q.max
+		}
+	}
+	if lowest >= d {
+		return false
+	}
+	delete(s.queries, fastest)
+	return true
+}
+
+// Top returns the slowest statements by maximum duration, slowest first.
+func (s *QueryStats) Top() []QueryStat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.topLocked()
+}
+
+// TopAndReset is Top followed by forgetting every statement, atomically so
+// no run is lost in between.
+func (s *QueryStats) TopAndReset() []QueryStat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	top := s.topLocked()
+	s.queries = map[string]*queryTotals{}
+	return top
+}
+
+func (s *QueryStats) topLocked() []QueryStat {
+	stats := make([]QueryStat, 0, len(s.queries))
+	for key, q := range s.queries {
+		stats = append(stats, QueryStat{
+			Query: key,
+			Count: q.count,
+			MaxMS: ms(q.max),
+			AvgMS: ms(q.total / time.Duration(q.count)),
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].MaxMS > stats[j].MaxMS })
+	if len(stats) > s.top {
+		stats = stats[:s.top]
+	}
+	return stats
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// fingerprint collapses whitespace and replaces string literals with "?".
+func fingerprint(sql string) string {
+	return strings.Join(strings.Fields(stringLiteral.ReplaceAllString(sql, "?")), " ")
+}
+
+// queryStatsTracer times every statement into a QueryStats.
+type queryStatsTracer struct {
+	stats *QueryStats
+}
+
+type queryStatsKey struct{}
+
+func (t *queryStatsTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryStatsKey{}, slowQueryStart{sql: data.SQL, start: time.Now()})
+}
+
+func (t *queryStatsTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryEndData) {
+	if q, ok := ctx.Value(queryStatsKey{}).(slowQueryStart); ok {
+		t.stats.record(q.sql, time.Since(q.start))
+	}
+}
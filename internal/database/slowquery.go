@@ -0,0 +1,44 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/manish-npx/simple-go-echo/internal/logger"
+)
+
+// slowQueryTracer logs statements that take longer than threshold at warn
+// level. Unlike the query log it stays quiet for normal traffic, so it can
+// run in production. Arguments are never logged.
+type slowQueryTracer struct {
+	log       logger.Logger
+	threshold time.Duration
+}
+
+type slowQueryKey struct{}
+
+type slowQueryStart struct {
+	sql   string
+	start time.Time
+}
+
+func (t *slowQueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, slowQueryKey{}, slowQueryStart{sql: data.SQL, start: time.Now()})
+}
+
+func (t *slowQueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	q, ok := ctx.Value(slowQueryKey{}).(slowQueryStart)
+	if !ok {
+		return
+	}
+
+	if d := time.Since(q.start); d > t.threshold {
+		t.log.Warn("slow query",
+			"sql", q.sql,
+			"duration", d,
+			"threshold", t.threshold,
+			"error", data.Err,
+		)
+	}
+}
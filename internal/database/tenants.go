@@ -0,0 +1,221 @@
+package database
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/manish-npx/simple-go-echo/internal/config"
+	"github.com/manish-npx/simple-go-echo/internal/logger"
+)
+
+// defaultMaxTenantPools applies when tenancy.max_pools is zero.
+const defaultMaxTenantPools = 10
+
+// ErrUnknownTenant is returned by TenantPools.Pool for a tenant that is not
+// configured.
+var ErrUnknownTenant = errors.New("unknown tenant")
+
+type tenantPool struct {
+	pool     *pgxpool.Pool
+	lastUsed time.Time
+	// leases counts the Leases not yet released. Only pools without any
+	// are closed.
+	leases int
+}
+
+// TenantPools holds one connection pool per tenant database. A pool is
+// opened on the tenant's first request, and at most max stay open: opening
+// another closes the least recently used one that no request holds. Each
+// tenant has its own circuit breaker, so one failing database doesn't
+// reject requests for the others.
+type TenantPools struct {
+	configs  map[string]*pgxpool.Config
+	breakers map[string]*Breaker
+	max      int
+	log      logger.Logger
+
+	mu    sync.Mutex
+	pools map[string]*tenantPool
+}
+
+// NewTenantPools parses every tenant URL up front, so a bad one fails at
+// startup rather than on that tenant's first request. It connects to
+// nothing yet.
+func NewTenantPools(cfg *config.Config, stats *QueryStats, log logger.Logger) (*TenantPools, error) {
+	configs := make(map[string]*pgxpool.Config, len(cfg.Tenancy.Tenants))
+	breakers := make(map[string]*Breaker, len(cfg.Tenancy.Tenants))
+	for name, dsn := range cfg.Tenancy.Tenants {
+		// The breaker outlives the tenant's pool, so an evicted and
+		// reopened pool stays open or closed as before.
+		breaker := NewBreaker(cfg.Database.BreakerThreshold, cfg.Database.BreakerCooldown)
+		poolCfg, err := poolConfig(dsn, cfg.Database, breaker, stats, log)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: %w", name, err)
+		}
+		configs[name] = poolCfg
+		breakers[name] = breaker
+	}
+	return &TenantPools{
+		configs:  configs,
+		breakers: breakers,
+		max:      cmp.Or(cfg.Tenancy.MaxPools, defaultMaxTenantPools),
+		log:      log,
+		pools:    map[string]*tenantPool{},
+	}, nil
+}
+
+// Names returns every configured tenant, sorted.
+func (t *TenantPools) Names() []string {
+	return slices.Sorted(maps.Keys(t.configs))
+}
+
+// Lease is a tenant's pool and breaker. The pool is not closed before
+// Release, however long the lease is held.
+type Lease struct {
+	Pool    *pgxpool.Pool
+	Breaker *Breaker
+
+	release sync.Once
+	t       *TenantPools
+	p       *tenantPool
+}
+
+// Release lets the pool be closed again. Calling it more than once is
+// harmless.
+func (l *Lease) Release() {
+	l.release.Do(func() { l.t.releaseLease(l.p) })
+}
+
+// Acquire leases the tenant's pool, opening it if needed. Callers must
+// Release the lease once they are done with the pool.
+func (t *TenantPools) Acquire(ctx context.Context, tenant string) (*Lease, error) {
+	poolCfg, ok := t.configs[tenant]
+	if !ok {
+		return nil, ErrUnknownTenant
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p, ok := t.pools[tenant]
+	if !ok {
+		if len(t.pools) >= t.max {
+			t.evictLocked()
+		}
+		// Pools connect lazily, so this doesn't wait on the database.
+		pool, err := pgxpool.NewWithConfig(ctx, poolCfg.Copy())
+		if err != nil {
+			return nil, fmt.Errorf("opening pool for tenant %s: %w", tenant, err)
+		}
+		p = &tenantPool{pool: pool}
+		t.pools[tenant] = p
+		t.log.Info("opened tenant database pool", "tenant", tenant, "open_pools", len(t.pools))
+	}
+	p.lastUsed = time.Now()
+	p.leases++
+	return &Lease{Pool: p.pool, Breaker: t.breakers[tenant], t: t, p: p}, nil
+}
+
+// releaseLease drops one lease on p. If every pool was leased when the
+// last one was opened, there are more than max; the surplus is closed as
+// soon as leases allow.
+func (t *TenantPools) releaseLease(p *tenantPool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p.leases--
+	for len(t.pools) > t.max {
+		if !t.evictLocked() {
+			break
+		}
+	}
+}
+
+// evictLocked closes the least recently used pool without leases, and
+// reports false if every pool is leased. Close waits for connections still
+// in use, so it runs in the background.
+func (t *TenantPools) evictLocked() bool {
+	var (
+		oldest string
+		at     time.Time
+	)
+	for name, p := range t.pools {
+		if p.leases == 0 && (oldest == "" || p.lastUsed.Before(at)) {
+			oldest, at = name, p.lastUsed
+		}
+	}
+	if oldest == "" {
+		return false
+	}
+	pool := t.pools[oldest].pool
+	delete(t.pools, oldest)
+	t.log.Info("closing idle tenant database pool", "tenant", oldest)
+	go pool.Close()
+	return true
+}
+
+// each calls fn for every open pool, holding the lock throughout.
+func (t *TenantPools) each(fn func(name string, pool *pgxpool.Pool)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for name, p := range t.pools {
+		fn(name, p.pool)
+	}
+}
+
+// Close closes every open tenant pool.
+func (t *TenantPools) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for name, p := range t.pools {
+		p.pool.Close()
+		delete(t.pools, name)
+	}
+}
+
+type tenantKey struct{}
+
+type tenantValue struct {
+	name  string
+	lease *Lease
+}
+
+// WithTenant returns a copy of ctx whose queries should go to the lease's
+// pool, guarded by its breaker.
+func WithTenant(ctx context.Context, name string, lease *Lease) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantValue{name: name, lease: lease})
+}
+
+// Tenant returns the tenant set by WithTenant, or "" in single-database
+// mode.
+func Tenant(ctx context.Context) string {
+	v, _ := ctx.Value(tenantKey{}).(tenantValue)
+	return v.name
+}
+
+// PoolFor returns the tenant pool set by WithTenant, or fallback when ctx
+// has none.
+func PoolFor(ctx context.Context, fallback *pgxpool.Pool) *pgxpool.Pool {
+	if v, ok := ctx.Value(tenantKey{}).(tenantValue); ok {
+		return v.lease.Pool
+	}
+	return fallback
+}
+
+// BreakerFor returns the tenant's breaker set by WithTenant, or fallback
+// when ctx has none.
+func BreakerFor(ctx context.Context, fallback *Breaker) *Breaker {
+	if v, ok := ctx.Value(tenantKey{}).(tenantValue); ok {
+		return v.lease.Breaker
+	}
+	return fallback
+}
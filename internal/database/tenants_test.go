@@ -0,0 +1,136 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/manish-npx/simple-go-echo/internal/config"
+	"github.com/manish-npx/simple-go-echo/internal/logger"
+)
+
+// newTestTenantPools configures tenants a, b and c on an address nothing
+// listens on. Pools connect lazily, so nothing is dialed.
+func newTestTenantPools(t *testing.T, maxPools int) *TenantPools {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Tenancy.MaxPools = maxPools
+	cfg.Tenancy.Tenants = map[string]string{
+		"a": "postgres://test@127.0.0.1:1/a",
+		"b": "postgres://test@127.0.0.1:1/b",
+		"c": "postgres://test@127.0.0.1:1/c",
+	}
+	pools, err := NewTenantPools(cfg, nil, logger.Nop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pools.Close)
+	return pools
+}
+
+func acquire(t *testing.T, pools *TenantPools, tenant string) *Lease {
+	t.Helper()
+	lease, err := pools.Acquire(context.Background(), tenant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lease
+}
+
+// open returns the tenants whose pools are open, sorted.
+func open(pools *TenantPools) []string {
+	var names []string
+	pools.each(func(name string, _ *pgxpool.Pool) { names = append(names, name) })
+	slices.Sort(names)
+	return names
+}
+
+func TestTenantPoolsEvictOnlyUnleased(t *testing.T) {
+	pools := newTestTenantPools(t, 1)
+
+	a := acquire(t, pools, "a")
+	b := acquire(t, pools, "b")
+	if got := open(pools); !slices.Equal(got, []string{"a", "b"}) {
+		t.Fatalf("open = %v, want a kept open while leased", got)
+	}
+
+	a.Release()
+	if got := open(pools); !slices.Equal(got, []string{"b"}) {
+		t.Errorf("open = %v, want a closed once released", got)
+	}
+	a.Release() // a second release must not disturb b's count
+
+	c := acquire(t, pools, "c")
+	if got := open(pools); !slices.Equal(got, []string{"b", "c"}) {
+		t.Errorf("open = %v, want b kept open while leased", got)
+	}
+	b.Release()
+	c.Release()
+	if got := open(pools); !slices.Equal(got, []string{"c"}) {
+		t.Errorf("open = %v, want only c", got)
+	}
+}
+
+func TestTenantPoolsReuseOpenPool(t *testing.T) {
+	pools := newTestTenantPools(t, 2)
+
+	first := acquire(t, pools, "a")
+	first.Release()
+	second := acquire(t, pools, "a")
+	defer second.Release()
+	if first.Pool != second.Pool {
+		t.Error("second lease got a new pool, want the open one")
+	}
+}
+
+func TestTenantPoolsBreakerPerTenant(t *testing.T) {
+	pools := newTestTenantPools(t, 1)
+
+	a := acquire(t, pools, "a")
+	a.Release()
+	b := acquire(t, pools, "b")
+	b.Release()
+	if a.Breaker == b.Breaker {
+		t.Fatal("tenants share a breaker")
+	}
+
+	for range defaultBreakerThreshold {
+		a.Breaker.Failure()
+	}
+	if a.Breaker.Allow() || !b.Breaker.Allow() {
+		t.Errorf("after a's failures: a allows %v, b allows %v; want only b", a.Breaker.Allow(), b.Breaker.Allow())
+	}
+
+	// a's pool was evicted for b's; reopening it keeps the open breaker.
+	again := acquire(t, pools, "a")
+	defer again.Release()
+	if again.Breaker != a.Breaker || again.Breaker.Allow() {
+		t.Error("reopened pool has a fresh breaker, want the tripped one")
+	}
+}
+
+func TestTenantContext(t *testing.T) {
+	pools := newTestTenantPools(t, 1)
+	lease := acquire(t, pools, "a")
+	defer lease.Release()
+
+	fallbackBreaker := NewBreaker(0, 0)
+	ctx := context.Background()
+	if Tenant(ctx) != "" || PoolFor(ctx, nil) != nil || BreakerFor(ctx, fallbackBreaker) != fallbackBreaker {
+		t.Error("plain context should give the fallbacks")
+	}
+
+	ctx = WithTenant(ctx, "a", lease)
+	if Tenant(ctx) != "a" || PoolFor(ctx, nil) != lease.Pool || BreakerFor(ctx, fallbackBreaker) != lease.Breaker {
+		t.Error("tenant context should give the lease's pool and breaker")
+	}
+}
+
+func TestTenantPoolsUnknownTenant(t *testing.T) {
+	pools := newTestTenantPools(t, 1)
+	if _, err := pools.Acquire(context.Background(), "zzz"); !errors.Is(err, ErrUnknownTenant) {
+		t.Errorf("err = %v, want ErrUnknownTenant", err)
+	}
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// breakerTracer reports query and acquire outcomes to a Breaker so it can
+// tell a healthy database from one that keeps dropping connections.
+type breakerTracer struct {
+	breaker *Breaker
+}
+
+func (t *breakerTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+	return ctx
+}
+
+func (t *breakerTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	t.record(ctx, data.Err)
+}
+
+func (t *breakerTracer) TraceAcquireStart(ctx context.Context, _ *pgxpool.Pool, _ pgxpool.TraceAcquireStartData) context.Context {
+	return ctx
+}
+
+func (t *breakerTracer) TraceAcquireEnd(ctx context.Context, _ *pgxpool.Pool, data pgxpool.TraceAcquireEndData) {
+	t.record(ctx, data.Err)
+}
+
+func (t *breakerTracer) record(ctx context.Context, err error) {
+	switch {
+	case err == nil:
+		t.breaker.Success()
+	case ctx.Err() != nil:
+		// The caller gave up; that says nothing about the database.
+	case isServerError(err):
+		// Postgres answered, so the connection itself is fine.
+		t.breaker.Success()
+	default:
+		t.breaker.Failure()
+	}
+}
+
+func isServerError(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) || errors.Is(err, pgx.ErrNoRows)
+}
@@ -0,0 +1,38 @@
+// Package errs defines the error kinds shared by storage and handlers.
+// Storage returns them (usually via the constructors, which carry a
+// client-safe message) and response.FromError maps them to HTTP statuses,
+// so handlers don't repeat the same errors.Is checks.
+package errs
+
+import "errors"
+
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+	ErrValidation = errors.New("validation failed")
+	ErrForbidden  = errors.New("forbidden")
+	// ErrUnauthorized means the caller is not authenticated at all, as
+	// opposed to ErrForbidden for an authenticated caller lacking access.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrPrecondition means a conditional request's precondition (e.g.
+	// If-None-Match: *) does not hold.
+	ErrPrecondition = errors.New("precondition failed")
+)
+
+// Error pairs a kind with a message that is safe to show clients.
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func (e *Error) Error() string { return e.Message }
+func (e *Error) Unwrap() error { return e.Kind }
+
+func NotFound(msg string) error  { return &Error{Kind: ErrNotFound, Message: msg} }
+func Conflict(msg string) error  { return &Error{Kind: ErrConflict, Message: msg} }
+func Invalid(msg string) error   { return &Error{Kind: ErrValidation, Message: msg} }
+func Forbidden(msg string) error { return &Error{Kind: ErrForbidden, Message: msg} }
+
+func Unauthorized(msg string) error { return &Error{Kind: ErrUnauthorized, Message: msg} }
+
+func PreconditionFailed(msg string) error { return &Error{Kind: ErrPrecondition, Message: msg} }
@@ -0,0 +1,27 @@
+// Package features holds the feature flags from config, so code can ship
+// dark and be switched on per environment.
+package features
+
+import "context"
+
+// Flags maps a feature name to whether it is on. Features missing from the
+// map are off.
+type Flags map[string]bool
+
+func (f Flags) Enabled(name string) bool {
+	return f[name]
+}
+
+type ctxKey struct{}
+
+// NewContext returns a copy of ctx carrying f.
+func NewContext(ctx context.Context, f Flags) context.Context {
+	return context.WithValue(ctx, ctxKey{}, f)
+}
+
+// Enabled reports whether the named feature is on for the request ctx
+// belongs to. Without flags in ctx every feature is off.
+func Enabled(ctx context.Context, name string) bool {
+	f, _ := ctx.Value(ctxKey{}).(Flags)
+	return f.Enabled(name)
+}
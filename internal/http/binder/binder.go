@@ -0,0 +1,222 @@
+package binder
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Error is returned when a request body cannot be bound. Message is safe to
+// show to clients.
+type Error struct {
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string { return e.Message }
+func (e *Error) Unwrap() error { return e.Err }
+
+// Default decode limits, generous for any real todo payload.
+const (
+	DefaultMaxDepth  = 32
+	DefaultMaxTokens = 10000
+)
+
+// Options configures a Binder. Zero limits mean the defaults.
+type Options struct {
+	// Strict rejects JSON bodies containing fields the target doesn't have.
+	Strict bool
+	// MaxDepth caps object/array nesting and MaxTokens the number of JSON
+	// tokens in a body, so hostile payloads are refused before decoding.
+	MaxDepth  int
+	MaxTokens int
+}
+
+// Binder decodes JSON bodies itself so it can report precise errors, and
+// falls back to Echo's DefaultBinder for everything else.
+type Binder struct {
+	// Strict, MaxDepth and MaxTokens are as in Options.
+	Strict    bool
+	MaxDepth  int
+	MaxTokens int
+
+	fallback echo.DefaultBinder
+}
+
+func New(opts Options) *Binder {
+	return &Binder{
+		Strict:    opts.Strict,
+		MaxDepth:  cmp.Or(opts.MaxDepth, DefaultMaxDepth),
+		MaxTokens: cmp.Or(opts.MaxTokens, DefaultMaxTokens),
+	}
+}
+
+func (b *Binder) Bind(i any, c echo.Context) error {
+	req := c.Request()
+	if req.ContentLength == 0 || !strings.HasPrefix(req.Header.Get(echo.HeaderContentType), echo.MIMEApplicationJSON) {
+		return b.fallback.Bind(i, c)
+	}
+
+	if err := b.fallback.BindPathParams(c, i); err != nil {
+		return err
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return &Error{Message: "Could not read request body", Err: err}
+	}
+
+	if msg := b.checkLimits(body); msg != "" {
+		return &Error{Message: msg}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if b.Strict {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(i); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			// Errors from a field type's own UnmarshalJSON (e.g.
+			// models.Bool) come back without the field name, and the
+			// decoder's own paths leave out array indexes.
+			if path := failingField(body, i); path != "" {
+				typeErr.Field = path
+			}
+		}
+		return &Error{Message: describe(err), Err: err}
+	}
+	if dec.More() {
+		return &Error{Message: "Request body must contain a single JSON value"}
+	}
+	return nil
+}
+
+// checkLimits walks the body's tokens and returns a client message if it
+// nests deeper than MaxDepth or holds more than MaxTokens tokens. Syntax
+// errors are left for the real decode, which describes them better.
+func (b *Binder) checkLimits(body []byte) string {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	depth, tokens := 0, 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return ""
+		}
+		tokens++
+		if tokens > b.MaxTokens {
+			return fmt.Sprintf("Request body has more than %d JSON tokens", b.MaxTokens)
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+			if depth > b.MaxDepth {
+				return fmt.Sprintf("Request body nests deeper than %d levels", b.MaxDepth)
+			}
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+			if depth == 0 {
+				return ""
+			}
+		}
+	}
+}
+
+// failingField returns the path of the value in body that does not decode
+// into i, such as "title" or "[2].todo.done", or "" if there is none.
+func failingField(body []byte, i any) string {
+	path, _ := failingPath(body, reflect.TypeOf(i))
+	return strings.TrimPrefix(path, ".")
+}
+
+// failingPath reports whether data fails to decode into t and, if so, the
+// path below data of the innermost value to blame: ".field" for struct
+// fields and "[i]" for array elements, or "" when it is data itself.
+func failingPath(data json.RawMessage, t reflect.Type) (string, bool) {
+	if json.Unmarshal(data, reflect.New(t).Interface()) == nil {
+		return "", false
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(data, &fields) != nil {
+			return "", true
+		}
+		for _, f := range reflect.VisibleFields(t) {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "" || name == "-" || !f.IsExported() {
+				continue
+			}
+			v, ok := fields[name]
+			if !ok {
+				continue
+			}
+			if path, failed := failingPath(v, f.Type); failed {
+				return "." + name + path, true
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		var items []json.RawMessage
+		if json.Unmarshal(data, &items) != nil {
+			return "", true
+		}
+		for i, v := range items {
+			if path, failed := failingPath(v, t.Elem()); failed {
+				return fmt.Sprintf("[%d]%s", i, path), true
+			}
+		}
+	}
+	return "", true
+}
+
+func describe(err error) string {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &syntaxErr):
+		return fmt.Sprintf("Malformed JSON at position %d: %s", syntaxErr.Offset, syntaxErr.Error())
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return fmt.Sprintf("Request body must be a JSON %s", jsonKind(typeErr.Type))
+		}
+		return fmt.Sprintf("Field %q must be a %s, got %s", typeErr.Field, jsonKind(typeErr.Type), typeErr.Value)
+	case errors.Is(err, io.EOF):
+		return "Request body is empty"
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return "Malformed JSON: unexpected end of body"
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		return "Unknown field " + strings.TrimPrefix(err.Error(), "json: unknown field ")
+	}
+	return "Invalid request body"
+}
+
+// jsonKind names a Go type the way a JSON client would think of it.
+func jsonKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map:
+		return "object"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Pointer:
+		return jsonKind(t.Elem())
+	default:
+		return "number"
+	}
+}
@@ -0,0 +1,148 @@
+package binder
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/manish-npx/simple-go-echo/internal/models"
+)
+
+type todoBody struct {
+	Title string `json:"title"`
+	Done  bool   `json:"done"`
+}
+
+func bind(b *Binder, body string) (todoBody, error) {
+	var v todoBody
+	err := bindInto(b, body, &v)
+	return v, err
+}
+
+func bindInto(b *Binder, body string, v any) error {
+	req := httptest.NewRequest(http.MethodPost, "/api/todos/create", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	c := echo.New().NewContext(req, httptest.NewRecorder())
+	return b.Bind(v, c)
+}
+
+func TestBindMalformedBodies(t *testing.T) {
+	tests := []struct {
+		name   string
+		strict bool
+		body   string
+		want   string
+	}{
+		{"syntax error", false, `{"title": "a",}`, "Malformed JSON at position 15"},
+		{"truncated", false, `{"title": "a"`, "Malformed JSON: unexpected end of body"},
+		{"wrong field type", false, `{"title": 5}`, `Field "title" must be a string, got number`},
+		{"wrong body type", false, `["a"]`, "Request body must be a JSON object"},
+		{"unknown field, strict", true, `{"title": "a", "priority": 1}`, `Unknown field "priority"`},
+		{"trailing value", false, `{"title": "a"} {}`, "Request body must contain a single JSON value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := bind(New(Options{Strict: tt.strict}), tt.body)
+			var bindErr *Error
+			if !errors.As(err, &bindErr) {
+				t.Fatalf("err = %v, want a *binder.Error", err)
+			}
+			if !strings.HasPrefix(bindErr.Message, tt.want) {
+				t.Errorf("message = %q, want prefix %q", bindErr.Message, tt.want)
+			}
+		})
+	}
+}
+
+func TestBindUnknownFieldAllowedByDefault(t *testing.T) {
+	v, err := bind(New(Options{}), `{"title": "a", "priority": 1}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Title != "a" {
+		t.Errorf("title = %q, want a", v.Title)
+	}
+}
+
+func TestBindEmptyBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(""))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.ContentLength = -1 // unknown, so the body is read
+	c := echo.New().NewContext(req, httptest.NewRecorder())
+
+	var bindErr *Error
+	if err := New(Options{}).Bind(new(todoBody), c); !errors.As(err, &bindErr) || bindErr.Message != "Request body is empty" {
+		t.Errorf("err = %v, want Request body is empty", err)
+	}
+}
+
+func TestBindDoneForms(t *testing.T) {
+	for body, want := range map[string]models.Bool{
+		`{"title": "a", "done": true}`:    true,
+		`{"title": "a", "done": false}`:   false,
+		`{"title": "a", "done": "true"}`:  true,
+		`{"title": "a", "done": "false"}`: false,
+		`{"title": "a", "done": 1}`:       true,
+		`{"title": "a", "done": 0}`:       false,
+	} {
+		var todo models.Todo
+		if err := bindInto(New(Options{}), body, &todo); err != nil {
+			t.Errorf("%s: %v", body, err)
+			continue
+		}
+		if todo.Done != want {
+			t.Errorf("%s: done = %v, want %v", body, todo.Done, want)
+		}
+	}
+}
+
+func TestBindNamesNestedField(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		v    any
+		want string
+	}{
+		{"top level", `{"title": "a", "done": "yes"}`, new(models.Todo), `Field "done" must be a boolean, got string "yes"`},
+		{"in a batch", `[{"op": "create", "todo": {"title": "a"}}, {"op": "update", "id": 1, "todo": {"title": "b", "done": 2}}]`, new([]models.TodoOp), `Field "[1].todo.done" must be a boolean, got number 2`},
+		{"decoder type error in a batch", `[{"op": "create", "todo": {"title": 5}}]`, new([]models.TodoOp), `Field "[0].todo.title" must be a string, got number`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bindErr *Error
+			if err := bindInto(New(Options{}), tt.body, tt.v); !errors.As(err, &bindErr) || bindErr.Message != tt.want {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBindLimits(t *testing.T) {
+	b := New(Options{MaxDepth: 3, MaxTokens: 8})
+
+	_, err := bind(b, `{"title": {"a": {"b": {"c": 1}}}}`)
+	if err == nil || !strings.Contains(err.Error(), "nests deeper than 3 levels") {
+		t.Errorf("deep body: err = %v, want a depth error", err)
+	}
+
+	_, err = bind(b, `{"title": "a", "done": true, "x": 1, "y": 2, "z": 3}`)
+	if err == nil || !strings.Contains(err.Error(), "more than 8 JSON tokens") {
+		t.Errorf("large body: err = %v, want a token error", err)
+	}
+
+	if _, err := bind(b, `{"title": "a", "done": true}`); err != nil {
+		t.Errorf("body within limits: %v", err)
+	}
+}
+
+func TestBindDefaultDepthLimit(t *testing.T) {
+	deep := `{"title": "a", "x": ` + strings.Repeat("[", DefaultMaxDepth) + strings.Repeat("]", DefaultMaxDepth) + `}`
+	_, err := bind(New(Options{}), deep)
+	var bindErr *Error
+	if !errors.As(err, &bindErr) || bindErr.Message != "Request body nests deeper than 32 levels" {
+		t.Errorf("err = %v, want the default depth error", err)
+	}
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/manish-npx/simple-go-echo/internal/utils/queryparams"
+	"github.com/manish-npx/simple-go-echo/internal/utils/response"
+)
+
+// Store is the storage a CrudHandler needs. Create must fill in the new
+// item's ID (and any other generated columns) before returning. Errors
+// should use the kinds in package errs, e.g. errs.NotFound for a missing
+// id, so they map to the right status.
+type Store[T any] interface {
+	// GetAll returns one page and the total number of matching items.
+	GetAll(ctx context.Context, q queryparams.ListQuery) ([]T, int, error)
+	GetByID(ctx context.Context, id int64) (*T, error)
+	Create(ctx context.Context, item *T) (int64, error)
+	Update(ctx context.Context, id int64, item *T) (*T, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+// CrudHandler implements the standard list/get/create/update/delete
+// endpoints for any resource backed by a Store. Resource handlers embed it
+// and shadow the methods they need to customise.
+type CrudHandler[T any] struct {
+	Store Store[T]
+	// Name identifies the resource, e.g. "Todo". Not-found messages come
+	// from the Store's errors via response.FromError.
+	Name string
+	// ListOptions and Fields configure ?limit=&sort=... and ?fields=.
+	ListOptions queryparams.Options
+	Fields      []string
+	// Validate runs after the `validate` struct tags on create and update,
+	// for rules tags can't express. Its message is returned as a 400.
+	Validate func(item *T) error
+}
+
+func (h *CrudHandler[T]) GetAll(c echo.Context) error {
+	q, err := queryparams.Parse(c.QueryParams(), h.ListOptions)
+	if err != nil {
+		return queryError(c, err)
+	}
+
+	fields, err := queryparams.ParseFields(c.QueryParams(), h.Fields)
+	if err != nil {
+		return queryError(c, err)
+	}
+
+	items, total, err := h.Store.GetAll(c.Request().Context(), q)
+	if err != nil {
+		return response.InternalServerError(c, err)
+	}
+	if items == nil {
+		// Clients expect an array; a nil slice would encode as null.
+		items = []T{}
+	}
+
+	response.SetPagination(c, q.Limit, q.Offset, total)
+	return okWithFields(c, items, fields)
+}
+
+func (h *CrudHandler[T]) GetByID(c echo.Context) error {
+	id, err := parseID(c)
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	fields, err := queryparams.ParseFields(c.QueryParams(), h.Fields)
+	if err != nil {
+		return queryError(c, err)
+	}
+
+	item, err := h.Store.GetByID(c.Request().Context(), id)
+	if err != nil {
+		return response.FromError(c, err)
+	}
+	return okWithFields(c, item, fields)
+}
+
+func (h *CrudHandler[T]) Create(c echo.Context) error {
+	item, err := h.bind(c)
+	if err != nil {
+		return requestError(c, err)
+	}
+
+	if _, err := h.Store.Create(c.Request().Context(), item); err != nil {
+		return response.FromError(c, err)
+	}
+	return response.Created(c, item)
+}
+
+func (h *CrudHandler[T]) Update(c echo.Context) error {
+	id, err := parseID(c)
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	item, err := h.bind(c)
+	if err != nil {
+		return requestError(c, err)
+	}
+
+	updated, err := h.Store.Update(c.Request().Context(), id, item)
+	if err != nil {
+		return response.FromError(c, err)
+	}
+	return response.OK(c, updated)
+}
+
+func (h *CrudHandler[T]) Delete(c echo.Context) error {
+	id, err := parseID(c)
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	if err := h.Store.Delete(c.Request().Context(), id); err != nil {
+		return response.FromError(c, err)
+	}
+	return response.NoContent(c)
+}
+
+// bind decodes the request body and runs the struct-tag validator followed
+// by the resource's own Validate hook.
+func (h *CrudHandler[T]) bind(c echo.Context) (*T, error) {
+	item := new(T)
+	if err := c.Bind(item); err != nil {
+		return nil, err
+	}
+	if err := c.Validate(item); err != nil {
+		return nil, err
+	}
+	if h.Validate != nil {
+		if err := h.Validate(item); err != nil {
+			return nil, err
+		}
+	}
+	return item, nil
+}
+
+// CrudActions is satisfied by *CrudHandler[T] and by any handler embedding
+// it, including ones that override individual actions.
+type CrudActions interface {
+	GetAll(c echo.Context) error
+	GetByID(c echo.Context) error
+	Create(c echo.Context) error
+	Update(c echo.Context) error
+	Delete(c echo.Context) error
+}
+
+// CrudRoutes holds the path for each action, relative to the group. An
+// empty path leaves that action unregistered.
+type CrudRoutes struct {
+	GetAll  string
+	GetByID string
+	Create  string
+	Update  string
+	Delete  string
+}
+
+func RegisterCrud(g *echo.Group, routes CrudRoutes, h CrudActions) {
+	register := func(method, path string, fn echo.HandlerFunc) {
+		if path != "" {
+			g.Add(method, path, fn)
+		}
+	}
+	register(echo.GET, routes.GetAll, h.GetAll)
+	register(echo.GET, routes.GetByID, h.GetByID)
+	register(echo.POST, routes.Create, h.Create)
+	register(echo.PUT, routes.Update, h.Update)
+	register(echo.DELETE, routes.Delete, h.Delete)
+}
+
+// parseID reads the :id path parameter. Its errors are client-facing
+// messages. Zero and negative ids never exist, so they are rejected here
+// without a database round trip.
+func parseID(c echo.Context) (int64, error) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	switch {
+	case errors.Is(err, strconv.ErrRange):
+		return 0, errors.New("Invalid ID: out of range")
+	case err != nil:
+		return 0, errors.New("Invalid ID: must be an integer")
+	case id < 1:
+		return 0, errors.New("Invalid ID: must be positive")
+	}
+	return id, nil
+}
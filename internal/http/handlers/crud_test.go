@@ -0,0 +1,357 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/manish-npx/simple-go-echo/internal/config"
+	"github.com/manish-npx/simple-go-echo/internal/models"
+	"github.com/manish-npx/simple-go-echo/internal/storage"
+	"github.com/manish-npx/simple-go-echo/internal/utils/queryparams"
+	"github.com/manish-npx/simple-go-echo/internal/utils/response"
+	"github.com/manish-npx/simple-go-echo/internal/validation"
+)
+
+// fakeStore is an in-memory Store[models.Todo]. lastQuery records the
+// query of the last GetAll. It is also a TodoStore, but only the methods
+// defined below work; the others panic on the nil embedded interface.
+type fakeStore struct {
+	TodoStore
+
+	todos     []models.Todo
+	lastQuery queryparams.ListQuery
+	err       error
+}
+
+func (s *fakeStore) GetAll(_ context.Context, q queryparams.ListQuery) ([]models.Todo, int, error) {
+	s.lastQuery = q
+	if s.err != nil {
+		return nil, 0, s.err
+	}
+	start := min(q.Offset, len(s.todos))
+	end := min(start+q.Limit, len(s.todos))
+	return s.todos[start:end], len(s.todos), nil
+}
+
+func (s *fakeStore) GetByID(_ context.Context, id int64) (*models.Todo, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	i := slices.IndexFunc(s.todos, func(t models.Todo) bool { return t.ID == id })
+	if i < 0 {
+		return nil, storage.ErrTodoNotFound
+	}
+	return &s.todos[i], nil
+}
+
+func (s *fakeStore) Create(_ context.Context, todo *models.Todo) (int64, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	todo.ID = int64(len(s.todos) + 1)
+	s.todos = append(s.todos, *todo)
+	return todo.ID, nil
+}
+
+func (s *fakeStore) Update(ctx context.Context, id int64, todo *models.Todo) (*models.Todo, error) {
+	existing, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	todo.ID = id
+	*existing = *todo
+	return existing, nil
+}
+
+func (s *fakeStore) Delete(ctx context.Context, id int64) error {
+	_, err := s.GetByID(ctx, id)
+	return err
+}
+
+// ExistsByTitle ignores case like the SQL it stands in for.
+func (s *fakeStore) ExistsByTitle(_ context.Context, title string) (bool, error) {
+	return slices.ContainsFunc(s.todos, func(t models.Todo) bool { return strings.EqualFold(t.Title, title) }), s.err
+}
+
+// Upsert replaces the todo with the same external_id or appends one.
+func (s *fakeStore) Upsert(ctx context.Context, todo *models.Todo) (*models.Todo, bool, error) {
+	i := slices.IndexFunc(s.todos, func(t models.Todo) bool {
+		return t.ExternalID != nil && *t.ExternalID == *todo.ExternalID
+	})
+	if i < 0 {
+		_, err := s.Create(ctx, todo)
+		return todo, true, err
+	}
+	todo.ID = s.todos[i].ID
+	s.todos[i] = *todo
+	return todo, false, s.err
+}
+
+// Duplicate appends a copy of todo id the way the SQL does.
+func (s *fakeStore) Duplicate(ctx context.Context, id int64) (*models.Todo, error) {
+	original, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	clone := models.Todo{Title: original.Title + " (copy)", Description: original.Description}
+	if _, err := s.Create(ctx, &clone); err != nil {
+		return nil, err
+	}
+	return &clone, nil
+}
+
+func (s *fakeStore) ExistsByExternalID(_ context.Context, externalID string) (bool, error) {
+	return slices.ContainsFunc(s.todos, func(t models.Todo) bool {
+		return t.ExternalID != nil && *t.ExternalID == externalID
+	}), s.err
+}
+
+// newTodoTestServer serves store's todos under /api like NewServer does,
+// with the list options of the todo handler.
+func newTodoTestServer(store *fakeStore, opts queryparams.Options) *echo.Echo {
+	e := echo.New()
+	h := &CrudHandler[models.Todo]{
+		Store:       store,
+		Name:        "Todo",
+		ListOptions: opts,
+		Fields:      todoFields,
+	}
+	RegisterCrud(e.Group("/api"), CrudRoutes{
+		GetAll:  "/todos",
+		GetByID: "/todos/:id",
+		Create:  "/todos/create",
+		Update:  "/todos/update/:id",
+		Delete:  "/todos/:id",
+	}, h)
+	return e
+}
+
+var testListOptions = queryparams.Options{
+	SortFields:   []string{"id", "title"},
+	DefaultSort:  "id",
+	BoolFilters:  []string{"done"},
+	DefaultLimit: 20,
+	MaxLimit:     100,
+}
+
+func serve(e *echo.Echo, method, target string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGetAllAndGetByIDNegotiateFormat(t *testing.T) {
+	store := &fakeStore{todos: []models.Todo{{ID: 1, Title: "First"}, {ID: 2, Title: "Second"}}}
+	e := newTodoTestServer(store, testListOptions)
+
+	for _, target := range []string{"/api/todos", "/api/todos/1"} {
+		t.Run(target+" JSON", func(t *testing.T) {
+			rec := serve(e, http.MethodGet, target, map[string]string{echo.HeaderAccept: echo.MIMEApplicationJSON})
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", rec.Code)
+			}
+			if ct := rec.Header().Get(echo.HeaderContentType); !strings.HasPrefix(ct, echo.MIMEApplicationJSON) {
+				t.Errorf("Content-Type = %q, want JSON", ct)
+			}
+			if !json.Valid(rec.Body.Bytes()) || !strings.Contains(rec.Body.String(), `"title":"First"`) {
+				t.Errorf("body = %s, want the todo as JSON", rec.Body)
+			}
+		})
+
+		t.Run(target+" XML", func(t *testing.T) {
+			rec := serve(e, http.MethodGet, target, map[string]string{echo.HeaderAccept: echo.MIMEApplicationXML})
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", rec.Code)
+			}
+			if ct := rec.Header().Get(echo.HeaderContentType); !strings.HasPrefix(ct, echo.MIMEApplicationXML) {
+				t.Errorf("Content-Type = %q, want XML", ct)
+			}
+			if err := xml.Unmarshal(rec.Body.Bytes(), new(any)); err != nil {
+				t.Errorf("body is not XML: %v\n%s", err, rec.Body)
+			}
+			if body := rec.Body.String(); !strings.Contains(body, "<todo><id>1</id>") || !strings.Contains(body, "<title>First</title>") {
+				t.Errorf("body = %s, want the todo as XML", rec.Body)
+			}
+		})
+
+		t.Run(target+" browser", func(t *testing.T) {
+			rec := serve(e, http.MethodGet, target, map[string]string{
+				echo.HeaderAccept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+			})
+			if ct := rec.Header().Get(echo.HeaderContentType); !strings.HasPrefix(ct, echo.MIMEApplicationJSON) {
+				t.Errorf("Content-Type = %q, want JSON by default", ct)
+			}
+		})
+	}
+}
+
+func TestGetAllReportsClampedLimit(t *testing.T) {
+	store := &fakeStore{}
+	e := newTodoTestServer(store, testListOptions)
+
+	rec := serve(e, http.MethodGet, "/api/todos?limit=1000000", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if store.lastQuery.Limit != 100 {
+		t.Errorf("storage got limit %d, want 100", store.lastQuery.Limit)
+	}
+	if got := rec.Header().Get("X-Pagination-Limit"); got != "100" {
+		t.Errorf("X-Pagination-Limit = %q, want 100", got)
+	}
+}
+
+func TestInvalidIDsAreRejectedBeforeStorage(t *testing.T) {
+	// Any storage call would turn into a 500.
+	store := &fakeStore{err: errors.New("storage must not be called")}
+	e := newTodoTestServer(store, testListOptions)
+
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"9223372036854775808", "Invalid ID: out of range"},
+		{"-9223372036854775809", "Invalid ID: out of range"},
+		{"-1", "Invalid ID: must be positive"},
+		{"0", "Invalid ID: must be positive"},
+		{"abc", "Invalid ID: must be an integer"},
+		{"1.5", "Invalid ID: must be an integer"},
+	}
+	for _, tt := range tests {
+		for _, req := range []struct{ method, target string }{
+			{http.MethodGet, "/api/todos/" + tt.id},
+			{http.MethodDelete, "/api/todos/" + tt.id},
+			{http.MethodPut, "/api/todos/update/" + tt.id},
+		} {
+			rec := serve(e, req.method, req.target, nil)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("%s %s: status %d, want 400", req.method, req.target, rec.Code)
+				continue
+			}
+			var body map[string]string
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body["error"] != tt.want {
+				t.Errorf("%s %s: body %s, want error %q", req.method, req.target, rec.Body, tt.want)
+			}
+		}
+	}
+}
+
+func TestGetAllUsesConfiguredDefaultLimit(t *testing.T) {
+	store := &fakeStore{}
+	opts := NewTodoHandler(nil, config.API{DefaultPageSize: 7, MaxPageSize: 50}).ListOptions
+	e := newTodoTestServer(store, opts)
+
+	rec := serve(e, http.MethodGet, "/api/todos", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if store.lastQuery.Limit != 7 {
+		t.Errorf("storage got limit %d, want the configured default 7", store.lastQuery.Limit)
+	}
+	if got := rec.Header().Get("X-Pagination-Limit"); got != "7" {
+		t.Errorf("X-Pagination-Limit = %q, want 7", got)
+	}
+
+	rec = serve(e, http.MethodGet, "/api/todos?limit=80", nil)
+	if got := rec.Header().Get("X-Pagination-Limit"); got != "50" {
+		t.Errorf("X-Pagination-Limit = %q, want the configured max 50", got)
+	}
+}
+
+func TestCreateOverLengthTitle(t *testing.T) {
+	post := func(e *echo.Echo, title string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/todos/create", strings.NewReader(`{"title": "`+title+`"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("caught by validation", func(t *testing.T) {
+		e := newTodoTestServer(&fakeStore{}, testListOptions)
+		e.Validator = validation.New(validation.Limits{})
+		rec := post(e, strings.Repeat("t", validation.MaxTitleLength+1))
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"title":"must be at most 255 characters"`) {
+			t.Errorf("got %d %s, want 400 naming title", rec.Code, rec.Body)
+		}
+	})
+
+	t.Run("rejected by the database", func(t *testing.T) {
+		// As if a write path skipped validation and Postgres raised 22001.
+		e := newTodoTestServer(&fakeStore{err: storage.ErrTodoTooLong}, testListOptions)
+		e.Validator = validation.New(validation.Limits{})
+		rec := post(e, "fits")
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "Title or description too long") {
+			t.Errorf("got %d %s, want 400 too long", rec.Code, rec.Body)
+		}
+	})
+}
+
+// hangUpStore is a fakeStore whose GetByID runs until the request is
+// cancelled, like a query the client hangs up on, and records what it saw.
+type hangUpStore struct {
+	*fakeStore
+	started chan struct{}
+	err     error
+}
+
+func (s *hangUpStore) GetByID(ctx context.Context, id int64) (*models.Todo, error) {
+	close(s.started)
+	<-ctx.Done()
+	s.err = ctx.Err()
+	return nil, fmt.Errorf("get todo %d: %w", id, s.err)
+}
+
+func TestClientGoneMidQuery(t *testing.T) {
+	store := &hangUpStore{fakeStore: &fakeStore{}, started: make(chan struct{})}
+	e := echo.New()
+	var status int
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			err := next(c)
+			status = c.Response().Status
+			return err
+		}
+	})
+	h := &CrudHandler[models.Todo]{Store: store, Name: "Todo", ListOptions: testListOptions, Fields: todoFields}
+	e.GET("/api/todos/:id", h.GetByID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-store.started
+		cancel()
+	}()
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/todos/1", nil).WithContext(ctx))
+
+	if !errors.Is(store.err, context.Canceled) {
+		t.Errorf("storage saw %v, want context.Canceled", store.err)
+	}
+	if status != response.StatusClientClosedRequest {
+		t.Errorf("logged status = %d, want %d", status, response.StatusClientClosedRequest)
+	}
+	if rec.Body.Len() != 0 || rec.Header().Get(echo.HeaderContentType) != "" {
+		t.Errorf("wrote %q (%s), want nothing", rec.Body, rec.Header().Get(echo.HeaderContentType))
+	}
+}
+
+func TestGetAllEmptyIsArray(t *testing.T) {
+	// fakeStore{} returns a nil slice, as pgx does for no rows.
+	rec := serve(newTodoTestServer(&fakeStore{}, testListOptions), http.MethodGet, "/api/todos", nil)
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Errorf("got %d %q, want 200 []", rec.Code, rec.Body)
+	}
+}
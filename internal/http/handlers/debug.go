@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/manish-npx/simple-go-echo/internal/config"
+	"github.com/manish-npx/simple-go-echo/internal/database"
+	"github.com/manish-npx/simple-go-echo/internal/utils/response"
+	"gopkg.in/yaml.v3"
+)
+
+// DebugConfig shows the configuration the server is actually running with,
+// env overrides included and secrets redacted, keyed by the YAML names.
+func DebugConfig(cfg *config.Config) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		// Round-trip through YAML so keys and durations ("30s") read the
+		// same as in config.yaml.
+		raw, err := yaml.Marshal(cfg.Redacted())
+		if err != nil {
+			return response.InternalServerError(c, err)
+		}
+		var out map[string]any
+		if err := yaml.Unmarshal(raw, &out); err != nil {
+			return response.InternalServerError(c, err)
+		}
+		return response.OK(c, out)
+	}
+}
+
+// SlowQueries lists the slowest statements seen since startup or the last
+// reset, e.g. [{"query": "SELECT ...", "count": 42, "max_ms": 310.5,
+// "avg_ms": 12.1}]. With ?reset=true the list is cleared after it is read.
+func SlowQueries(stats *database.QueryStats) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		reset, err := parseBoolParam(c, "reset")
+		if err != nil {
+			return response.BadRequest(c, "reset must be true or false")
+		}
+
+		if reset {
+			return response.OK(c, stats.TopAndReset())
+		}
+		return response.OK(c, stats.Top())
+	}
+}
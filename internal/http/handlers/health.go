@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/echo/v4"
+	"github.com/manish-npx/simple-go-echo/internal/utils/response"
+)
+
+const (
+	readyPingTimeout = 2 * time.Second
+
+	// undefinedTable is the Postgres error code for a missing relation.
+	undefinedTable = "42P01"
+)
+
+type HealthHandler struct {
+	db       *pgxpool.Pool
+	draining atomic.Bool
+	// checkSchema makes /ready also query the todos table, so a database
+	// without migrations is reported instead of failing on first use.
+	checkSchema bool
+}
+
+func NewHealthHandler(db *pgxpool.Pool, checkSchema bool) *HealthHandler {
+	return &HealthHandler{db: db, checkSchema: checkSchema}
+}
+
+// StartDraining makes /ready fail so load balancers stop sending traffic
+// before the server shuts down.
+func (h *HealthHandler) StartDraining() {
+	h.draining.Store(true)
+}
+
+// Health is the liveness probe: the process is up and serving.
+func (h *HealthHandler) Health(c echo.Context) error {
+	return response.OK(c, map[string]string{"status": "ok"})
+}
+
+// Ready is the readiness probe: the server accepts traffic and the
+// database answers. With tenancy that is the main database only. A probe
+// names no tenant, and one tenant's outage shouldn't take the instance out
+// of rotation for the rest; their circuit breakers answer 503 instead.
+func (h *HealthHandler) Ready(c echo.Context) error {
+	if h.draining.Load() {
+		return response.WithStatus(c, http.StatusServiceUnavailable, map[string]string{"status": "draining"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), readyPingTimeout)
+	defer cancel()
+
+	if err := h.db.Ping(ctx); err != nil {
+		return response.WithStatus(c, http.StatusServiceUnavailable, map[string]string{"status": "database unavailable"})
+	}
+
+	if h.checkSchema {
+		if _, err := h.db.Exec(ctx, `SELECT 1 FROM todos LIMIT 1`); err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == undefinedTable {
+				return response.WithStatus(c, http.StatusServiceUnavailable, map[string]string{"status": "todos table missing, run migrations"})
+			}
+			return response.WithStatus(c, http.StatusServiceUnavailable, map[string]string{"status": "database unavailable"})
+		}
+	}
+	return response.OK(c, map[string]string{"status": "ready"})
+}
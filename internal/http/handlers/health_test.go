@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/echo/v4"
+)
+
+func TestReadyFailureEnvelope(t *testing.T) {
+	// Nothing listens on port 1, so every ping fails straight away.
+	pool, err := pgxpool.New(context.Background(), "postgres://test@127.0.0.1:1/test?connect_timeout=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	draining := NewHealthHandler(pool, false)
+	draining.StartDraining()
+	down := NewHealthHandler(pool, true)
+
+	tests := []struct {
+		name        string
+		h           *HealthHandler
+		accept      string
+		contentType string
+		body        string
+	}{
+		{"draining", draining, "", echo.MIMEApplicationJSON, `{"status":"draining"}`},
+		{"database down", down, "", echo.MIMEApplicationJSON, `{"status":"database unavailable"}`},
+		{"database down as XML", down, echo.MIMEApplicationXML, echo.MIMEApplicationXML, `<status>database unavailable</status>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.GET("/ready", tt.h.Ready)
+			rec := serve(e, http.MethodGet, "/ready", map[string]string{echo.HeaderAccept: tt.accept})
+
+			if rec.Code != http.StatusServiceUnavailable {
+				t.Errorf("status = %d, want 503", rec.Code)
+			}
+			if ct := rec.Header().Get(echo.HeaderContentType); !strings.HasPrefix(ct, tt.contentType) {
+				t.Errorf("Content-Type = %q, want %s", ct, tt.contentType)
+			}
+			if rec.Header().Get(echo.HeaderVary) != echo.HeaderAccept {
+				t.Errorf("Vary = %q, want Accept", rec.Header().Get(echo.HeaderVary))
+			}
+			if !strings.Contains(rec.Body.String(), tt.body) {
+				t.Errorf("body = %s, want %s", rec.Body, tt.body)
+			}
+		})
+	}
+}
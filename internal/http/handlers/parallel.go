@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// parallel runs independent reads concurrently, so a handler needing
+// several of them waits for the slowest rather than their sum. Each fn gets
+// a context derived from ctx: it keeps the request's deadline, and the
+// first error cancels the others' queries. It returns that first error.
+func parallel(ctx context.Context, fns ...func(ctx context.Context) error) error {
+	g, ctx := errgroup.WithContext(ctx)
+	for _, fn := range fns {
+		g.Go(func() error { return fn(ctx) })
+	}
+	return g.Wait()
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParallelRunsConcurrently(t *testing.T) {
+	// Each fn waits for the other to start, which only works if they run
+	// at the same time.
+	var started sync.WaitGroup
+	started.Add(2)
+	both := make(chan struct{})
+	go func() { started.Wait(); close(both) }()
+
+	fn := func(ctx context.Context) error {
+		started.Done()
+		select {
+		case <-both:
+			return nil
+		case <-time.After(time.Second):
+			return errors.New("ran sequentially")
+		}
+	}
+	if err := parallel(context.Background(), fn, fn); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParallelFirstErrorCancelsOthers(t *testing.T) {
+	boom := errors.New("boom")
+	var otherErr error
+	err := parallel(context.Background(),
+		func(ctx context.Context) error { return boom },
+		func(ctx context.Context) error {
+			select {
+			case <-ctx.Done():
+				otherErr = ctx.Err()
+				return otherErr
+			case <-time.After(time.Second):
+				return nil
+			}
+		},
+	)
+	if !errors.Is(err, boom) {
+		t.Errorf("err = %v, want the first error", err)
+	}
+	if !errors.Is(otherErr, context.Canceled) {
+		t.Errorf("other fn saw %v, want context.Canceled", otherErr)
+	}
+}
+
+func TestParallelKeepsDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	want, _ := ctx.Deadline()
+
+	err := parallel(ctx, func(ctx context.Context) error {
+		if got, ok := ctx.Deadline(); !ok || !got.Equal(want) {
+			t.Errorf("deadline = %v (%v), want %v", got, ok, want)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
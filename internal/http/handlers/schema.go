@@ -0,0 +1,16 @@
+package handlers
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/manish-npx/simple-go-echo/internal/utils/response"
+	"github.com/manish-npx/simple-go-echo/internal/validation"
+)
+
+// Schema serves the JSON Schema of model for client code generators. It is
+// derived from the struct once, at startup.
+func Schema(v *validation.Validator, model any) echo.HandlerFunc {
+	doc := v.Schema(model)
+	return func(c echo.Context) error {
+		return response.OK(c, doc)
+	}
+}
@@ -1,94 +1,725 @@
 package handlers
 
 import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/manish-npx/simple-go-echo/internal/config"
+	"github.com/manish-npx/simple-go-echo/internal/errs"
+	"github.com/manish-npx/simple-go-echo/internal/http/binder"
+	"github.com/manish-npx/simple-go-echo/internal/logger"
 	"github.com/manish-npx/simple-go-echo/internal/models"
 	"github.com/manish-npx/simple-go-echo/internal/storage"
+	"github.com/manish-npx/simple-go-echo/internal/utils/queryparams"
 	"github.com/manish-npx/simple-go-echo/internal/utils/response"
+	"github.com/manish-npx/simple-go-echo/internal/validation"
 )
 
+// todoFields are the JSON keys clients may request via ?fields=.
+var todoFields = []string{"id", "public_id", "title", "description", "done", "external_id", "created_at", "updated_at"}
+
+const (
+	defaultRecentLimit = 10
+	maxRecentLimit     = 50
+
+	// maxBatchOps caps one POST /todos/batch-ops request, which runs in a
+	// single transaction.
+	maxBatchOps = 100
+)
+
+// TodoStore is the storage a TodoHandler needs on top of Store.
+// *storage.TodoStorage implements it.
+type TodoStore interface {
+	Store[models.Todo]
+	LastModified(ctx context.Context) (time.Time, int64, error)
+	GetByIDs(ctx context.Context, ids []int64) ([]models.Todo, error)
+	ExistsByTitle(ctx context.Context, title string) (bool, error)
+	ExistsByExternalID(ctx context.Context, externalID string) (bool, error)
+	Upsert(ctx context.Context, todo *models.Todo) (*models.Todo, bool, error)
+	ApplyOps(ctx context.Context, ops []models.TodoOp) ([]models.TodoOpResult, error)
+	CountWhere(ctx context.Context, filters map[string]bool) (int, error)
+	Stats(ctx context.Context, bucket string, from, to time.Time) ([]models.TodoStats, error)
+	GetGrouped(ctx context.Context, q queryparams.ListQuery) (pending, done []models.Todo, err error)
+	GetAdjacent(ctx context.Context, id int64, q queryparams.ListQuery) (prev, next *models.Todo, err error)
+	StreamAll(ctx context.Context, q queryparams.ListQuery, fn func(*models.Todo) error) error
+	GetRecent(ctx context.Context, limit int) ([]models.Todo, error)
+	Patch(ctx context.Context, id int64, patch models.TodoPatch) (*models.Todo, []string, error)
+	ToggleDone(ctx context.Context, id int64) (*models.Todo, error)
+	Duplicate(ctx context.Context, id int64) (*models.Todo, error)
+	SetAllDone(ctx context.Context, done bool) (int64, error)
+	PreviewSetAllDone(ctx context.Context, done bool) ([]models.Todo, error)
+}
+
 type TodoHandler struct {
-	storage *storage.TodoStorage
+	*CrudHandler[models.Todo]
+
+	storage     TodoStore
+	cacheMaxAge time.Duration
 }
 
-func NewTodoHandler(storage *storage.TodoStorage) *TodoHandler {
-	return &TodoHandler{storage: storage}
+func NewTodoHandler(storage TodoStore, api config.API) *TodoHandler {
+	return &TodoHandler{
+		CrudHandler: &CrudHandler[models.Todo]{
+			Store: storage,
+			Name:  "Todo",
+			ListOptions: queryparams.Options{
+				SortFields:   []string{"id", "title", "done", "created_at", "updated_at"},
+				DefaultSort:  "id",
+				BoolFilters:  []string{"done"},
+				DefaultLimit: api.DefaultPageSize,
+				MaxLimit:     api.MaxPageSize,
+			},
+			Fields: todoFields,
+		},
+		storage:     storage,
+		cacheMaxAge: api.CacheMaxAge,
+	}
 }
 
+// GetAll adds HTTP caching on top of the generic list.
 func (h *TodoHandler) GetAll(c echo.Context) error {
-	todos, err := h.storage.GetAll(c.Request().Context())
+	if h.cacheMaxAge > 0 {
+		lastModified, count, err := h.storage.LastModified(c.Request().Context())
+		if err != nil {
+			return response.InternalServerError(c, err)
+		}
+		// JSON, XML and JSON:API bodies of the same list are different
+		// representations, so each gets its own tag.
+		etag := fmt.Sprintf(`W/"%d-%d-%s"`, count, lastModified.UnixNano(), response.Representation(c))
+		if response.SetCacheHeaders(c, h.cacheMaxAge, lastModified, etag) {
+			return response.NotModified(c)
+		}
+	}
+	if c.QueryParams().Has("ids") {
+		return h.getByIDs(c)
+	}
+	return h.CrudHandler.GetAll(c)
+}
+
+// getByIDs serves GET /todos?ids=1,2,3 for clients resolving a set of
+// references in one call. Results follow the request order; ids that don't
+// exist are left out.
+func (h *TodoHandler) getByIDs(c echo.Context) error {
+	ids, err := queryparams.ParseIDs(c.QueryParams(), h.ListOptions.MaxLimit)
+	if err != nil {
+		return queryError(c, err)
+	}
+	if len(ids) == 0 {
+		return response.BadRequest(c, "ids must list at least one id")
+	}
+
+	fields, err := queryparams.ParseFields(c.QueryParams(), h.Fields)
+	if err != nil {
+		return queryError(c, err)
+	}
+
+	todos, err := h.storage.GetByIDs(c.Request().Context(), ids)
 	if err != nil {
 		return response.InternalServerError(c, err)
 	}
-	return response.OK(c, todos)
+	return okWithFields(c, todos, fields)
 }
 
-func (h *TodoHandler) GetByID(c echo.Context) error {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+// Create accepts ?check_duplicate=true to refuse a todo whose title
+// already exists (case-insensitively) with a 409. With If-None-Match: *
+// it only creates the todo if none exists yet, matching by external_id
+// when the body has one and by title otherwise, and answers 412 if one
+// does. Any other If-None-Match is ignored: a new todo has no ETag an
+// entity tag could match.
+func (h *TodoHandler) Create(c echo.Context) error {
+	check, err := parseBoolParam(c, "check_duplicate")
+	if err != nil {
+		return response.BadRequest(c, "check_duplicate must be true or false")
+	}
+	createOnly := c.Request().Header.Get("If-None-Match") == "*"
+	if !check && !createOnly {
+		return h.CrudHandler.Create(c)
+	}
+
+	todo, err := h.bind(c)
 	if err != nil {
-		return response.BadRequest(c, "Invalid ID")
+		return requestError(c, err)
 	}
 
-	todo, err := h.storage.GetByID(c.Request().Context(), id)
+	ctx := c.Request().Context()
+	if createOnly {
+		err = h.checkAbsent(ctx, todo)
+	} else {
+		err = h.checkTitleFree(ctx, todo)
+	}
 	if err != nil {
-		return response.NotFound(c, "Todo not found")
+		return response.FromError(c, err)
+	}
+
+	if _, err := h.storage.Create(ctx, todo); err != nil {
+		if createOnly && errors.Is(err, errs.ErrConflict) {
+			// Another request created the same external_id since the check.
+			err = errs.PreconditionFailed("A todo with this external_id already exists")
+		}
+		return response.FromError(c, err)
 	}
-	return response.OK(c, todo)
+	return response.Created(c, todo)
 }
 
-func (h *TodoHandler) Create(c echo.Context) error {
-	var todo models.Todo
-	if err := c.Bind(&todo); err != nil {
-		return response.BadRequest(c, "Invalid request body")
+// checkTitleFree returns a conflict if a todo with the same title exists.
+func (h *TodoHandler) checkTitleFree(ctx context.Context, todo *models.Todo) error {
+	exists, err := h.storage.ExistsByTitle(ctx, todo.Title)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errs.Conflict("A todo with this title already exists")
+	}
+	return nil
+}
+
+// checkAbsent is the If-None-Match: * precondition: no todo with the same
+// external_id, or the same title if there is none, may exist. The title
+// check is not atomic with the insert; external_id is also guarded by its
+// unique index.
+func (h *TodoHandler) checkAbsent(ctx context.Context, todo *models.Todo) error {
+	if todo.ExternalID != nil {
+		exists, err := h.storage.ExistsByExternalID(ctx, *todo.ExternalID)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return errs.PreconditionFailed("A todo with this external_id already exists")
+		}
+		return nil
+	}
+
+	exists, err := h.storage.ExistsByTitle(ctx, todo.Title)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errs.PreconditionFailed("A todo with this title already exists")
 	}
+	return nil
+}
 
-	if todo.Title == "" {
-		return response.BadRequest(c, "Title is required")
+// Upsert creates or updates the todo with the body's external_id, for
+// syncing from another system: 201 when it was created, 200 when an
+// existing todo was updated. Repeating a request changes nothing further.
+func (h *TodoHandler) Upsert(c echo.Context) error {
+	todo, err := h.bind(c)
+	if err != nil {
+		return requestError(c, err)
+	}
+	if todo.ExternalID == nil {
+		return response.ValidationError(c, "Validation failed", map[string]string{"external_id": "is required"})
 	}
 
-	id, err := h.storage.Create(c.Request().Context(), &todo)
+	upserted, created, err := h.storage.Upsert(c.Request().Context(), todo)
+	if err != nil {
+		return response.FromError(c, err)
+	}
+	if created {
+		return response.Created(c, upserted)
+	}
+	return response.OK(c, upserted)
+}
+
+// BatchOps applies an ordered list of create, update and delete operations
+// in one transaction, for clients flushing offline changes:
+//
+//	[{"op": "create", "todo": {"title": "a"}}, {"op": "delete", "id": 3}]
+//
+// It returns one result per operation. Every operation is validated first,
+// and if any is invalid a single 400 lists them all by index, e.g.
+// {"errors": [{"index": 2, "fields": {...}}]}, without touching the
+// database. If an operation then fails, nothing is applied and the error
+// carries its index.
+func (h *TodoHandler) BatchOps(c echo.Context) error {
+	var ops []models.TodoOp
+	if err := c.Bind(&ops); err != nil {
+		return bindError(c, err)
+	}
+	switch {
+	case len(ops) == 0:
+		return response.BadRequest(c, "Batch must contain at least one operation")
+	case len(ops) > maxBatchOps:
+		return response.BadRequest(c, fmt.Sprintf("Batch may contain at most %d operations", maxBatchOps))
+	}
+
+	var invalid []map[string]any
+	for i, op := range ops {
+		err := h.checkOp(c, op)
+		if err == nil {
+			continue
+		}
+		item, ok := response.ItemError(i, err)
+		if !ok {
+			return response.InternalServerError(c, err)
+		}
+		invalid = append(invalid, item)
+	}
+	if len(invalid) > 0 {
+		return response.BatchValidationError(c, invalid)
+	}
+
+	results, err := h.storage.ApplyOps(c.Request().Context(), ops)
+	var opErr *storage.OpError
+	if errors.As(err, &opErr) {
+		return response.FromErrorAt(c, opErr.Err, opErr.Index)
+	}
+	if err != nil {
+		return response.FromError(c, err)
+	}
+	return response.OK(c, results)
+}
+
+// checkOp validates one batch operation before anything is written.
+func (h *TodoHandler) checkOp(c echo.Context, op models.TodoOp) error {
+	switch op.Op {
+	case models.OpCreate, models.OpUpdate:
+		if op.Op == models.OpUpdate && op.ID < 1 {
+			return errs.Invalid("id must be a positive integer")
+		}
+		if op.Todo == nil {
+			return errs.Invalid("todo is required")
+		}
+		return c.Validate(op.Todo)
+	case models.OpDelete:
+		if op.ID < 1 {
+			return errs.Invalid("id must be a positive integer")
+		}
+		return nil
+	}
+	return errs.Invalid("op must be one of create, update, delete")
+}
+
+// Count returns {"count": n} for the same filters the list accepts, so the
+// UI can show totals without fetching rows.
+func (h *TodoHandler) Count(c echo.Context) error {
+	q, err := queryparams.Parse(c.QueryParams(), h.ListOptions)
+	if err != nil {
+		return queryError(c, err)
+	}
+
+	n, err := h.storage.CountWhere(c.Request().Context(), q.Filters)
 	if err != nil {
 		return response.InternalServerError(c, err)
 	}
+	return response.OK(c, map[string]any{"count": n})
+}
 
-	todo.ID = id
-	return response.Created(c, todo)
+const (
+	defaultStatsRange = 30 * 24 * time.Hour
+	maxStatsBuckets   = 1000
+)
+
+// statsBucketSize is roughly how long each bucket is, to cap the series.
+var statsBucketSize = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 28 * 24 * time.Hour,
+}
+
+// Stats returns created/completed counts per day, week or month for
+// charts: GET /todos/stats?from=2025-01-01&to=2025-02-01&bucket=week.
+// from and to accept RFC 3339 or YYYY-MM-DD and default to the last 30
+// days; the range is half-open, [from, to).
+func (h *TodoHandler) Stats(c echo.Context) error {
+	fields := map[string]string{}
+
+	bucket := c.QueryParam("bucket")
+	if bucket == "" {
+		bucket = "day"
+	}
+	size, ok := statsBucketSize[bucket]
+	if !ok {
+		fields["bucket"] = "must be one of day, week, month"
+	}
+
+	to, err := parseTimeParam(c.QueryParam("to"), time.Now())
+	if err != nil {
+		fields["to"] = err.Error()
+	}
+	from, err := parseTimeParam(c.QueryParam("from"), to.Add(-defaultStatsRange))
+	if err != nil {
+		fields["from"] = err.Error()
+	}
+
+	if len(fields) == 0 {
+		switch {
+		case !from.Before(to):
+			fields["from"] = "must be before to"
+		case to.Sub(from)/size > maxStatsBuckets:
+			fields["bucket"] = fmt.Sprintf("range spans more than %d buckets", maxStatsBuckets)
+		}
+	}
+	if len(fields) > 0 {
+		return queryError(c, &queryparams.Error{Fields: fields})
+	}
+
+	stats, err := h.storage.Stats(c.Request().Context(), bucket, from, to)
+	if err != nil {
+		return response.InternalServerError(c, err)
+	}
+	return response.OK(c, stats)
+}
+
+// parseTimeParam accepts RFC 3339 or a bare date; empty returns def.
+func parseTimeParam(v string, def time.Time) (time.Time, error) {
+	if v == "" {
+		return def, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, nil
+	}
+	return def, errors.New("must be an RFC 3339 time or YYYY-MM-DD date")
+}
+
+type groupedTodos struct {
+	XMLName xml.Name      `json:"-" xml:"todos"`
+	Pending []models.Todo `json:"pending" xml:"pending>todo"`
+	Done    []models.Todo `json:"done" xml:"done>todo"`
+}
+
+// GetGrouped lists pending and done todos side by side for board views.
+// limit, offset and sort apply within each group.
+func (h *TodoHandler) GetGrouped(c echo.Context) error {
+	opts := h.ListOptions
+	opts.BoolFilters = nil
+	q, err := queryparams.Parse(c.QueryParams(), opts)
+	if err != nil {
+		return queryError(c, err)
+	}
+
+	pending, done, err := h.storage.GetGrouped(c.Request().Context(), q)
+	if err != nil {
+		return response.InternalServerError(c, err)
+	}
+	return response.OK(c, groupedTodos{Pending: pending, Done: done})
+}
+
+type adjacentTodos struct {
+	XMLName xml.Name     `json:"-" xml:"adjacent"`
+	Prev    *models.Todo `json:"prev" xml:"prev>todo,omitempty"`
+	Next    *models.Todo `json:"next" xml:"next>todo,omitempty"`
+}
+
+// GetAdjacent returns the todos before and after :id for next/previous
+// navigation, e.g. {"prev": {...}, "next": null} on the last todo. It takes
+// the list's sort and filter parameters so it matches the list the client
+// came from.
+func (h *TodoHandler) GetAdjacent(c echo.Context) error {
+	id, err := parseID(c)
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	q, err := queryparams.Parse(c.QueryParams(), h.ListOptions)
+	if err != nil {
+		return queryError(c, err)
+	}
+
+	prev, next, err := h.storage.GetAdjacent(c.Request().Context(), id, q)
+	if err != nil {
+		return response.FromError(c, err)
+	}
+	return response.OK(c, adjacentTodos{Prev: prev, Next: next})
+}
+
+type todoDetail struct {
+	XMLName xml.Name     `json:"-" xml:"detail"`
+	Todo    *models.Todo `json:"todo" xml:"todo"`
+	Prev    *models.Todo `json:"prev" xml:"prev>todo,omitempty"`
+	Next    *models.Todo `json:"next" xml:"next>todo,omitempty"`
+}
+
+// GetDetail returns :id together with its neighbours, for a detail view
+// with next/previous links, e.g. {"todo": {...}, "prev": null, "next":
+// {...}}. The two lookups run concurrently under the request's deadline.
+// It takes the list's sort and filter parameters like GetAdjacent.
+func (h *TodoHandler) GetDetail(c echo.Context) error {
+	id, err := parseID(c)
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	q, err := queryparams.Parse(c.QueryParams(), h.ListOptions)
+	if err != nil {
+		return queryError(c, err)
+	}
+
+	var d todoDetail
+	err = parallel(c.Request().Context(),
+		func(ctx context.Context) (err error) {
+			d.Todo, err = h.storage.GetByID(ctx, id)
+			return err
+		},
+		func(ctx context.Context) (err error) {
+			d.Prev, d.Next, err = h.storage.GetAdjacent(ctx, id, q)
+			return err
+		},
+	)
+	if err != nil {
+		return response.FromError(c, err)
+	}
+	return response.OK(c, d)
 }
 
-func (h *TodoHandler) Update(c echo.Context) error {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+// streamFlushEvery is how many todos Stream writes between flushes.
+const streamFlushEvery = 100
+
+// Stream writes every todo matching the list's sort and filters as a single
+// JSON array, for clients syncing the whole list. Each todo is encoded as
+// its row arrives from the database and the response is flushed every
+// streamFlushEvery todos, so memory stays flat however many rows there
+// are. limit and offset are ignored.
+//
+// Errors before the first todo get the usual error response. Once the
+// array has started the status is already sent, so a later error aborts
+// the connection instead: the client sees a truncated body rather than a
+// complete-looking array. That is also why the route is exempt from the
+// request timeout unless server.route_timeouts gives it one.
+func (h *TodoHandler) Stream(c echo.Context) error {
+	q, err := queryparams.Parse(c.QueryParams(), h.ListOptions)
 	if err != nil {
-		return response.BadRequest(c, "Invalid ID")
+		return queryError(c, err)
+	}
+	return streamJSON(c, func(ctx context.Context, fn func(*models.Todo) error) error {
+		return h.storage.StreamAll(ctx, q, fn)
+	})
+}
+
+// streamJSON writes the todos each passes to fn as one JSON array, as
+// described on Stream.
+func streamJSON(c echo.Context, each func(ctx context.Context, fn func(*models.Todo) error) error) error {
+	res := c.Response()
+	flusher := http.NewResponseController(res.Writer)
+	begin := func() error {
+		res.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		res.WriteHeader(http.StatusOK)
+		_, err := io.WriteString(res, "[")
+		return err
+	}
+
+	n := 0
+	err := each(c.Request().Context(), func(todo *models.Todo) error {
+		sep := ","
+		if n == 0 {
+			if err := begin(); err != nil {
+				return err
+			}
+			sep = ""
+		}
+		if _, err := io.WriteString(res, sep); err != nil {
+			return err
+		}
+		// The serializer applies the configured json_naming.
+		if err := c.Echo().JSONSerializer.Serialize(c, todo, ""); err != nil {
+			return err
+		}
+		n++
+		if n%streamFlushEvery == 0 {
+			// Writers that can't flush just buffer; that's fine.
+			_ = flusher.Flush()
+		}
+		return nil
+	})
+	switch {
+	case err != nil && n == 0:
+		return response.InternalServerError(c, err)
+	case err != nil:
+		if !response.ClientGone(c) {
+			logger.FromContext(c.Request().Context()).Error("todo stream failed mid-response", "error", err, "written", n)
+		}
+		panic(http.ErrAbortHandler)
+	case n == 0:
+		if err := begin(); err != nil {
+			return err
+		}
 	}
+	_, err = io.WriteString(res, "]\n")
+	return err
+}
 
-	var todo models.Todo
-	if err := c.Bind(&todo); err != nil {
-		return response.BadRequest(c, "Invalid request body")
+// GetRecent lists the most recently changed todos for activity feeds.
+func (h *TodoHandler) GetRecent(c echo.Context) error {
+	limit := defaultRecentLimit
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return response.BadRequest(c, "limit must be a positive integer")
+		}
+		limit = min(n, maxRecentLimit)
 	}
 
-	if todo.Title == "" {
-		return response.BadRequest(c, "Title is required")
+	todos, err := h.storage.GetRecent(c.Request().Context(), limit)
+	if err != nil {
+		return response.InternalServerError(c, err)
 	}
+	return response.OK(c, todos)
+}
+
+type patchResult struct {
+	XMLName xml.Name     `json:"-" xml:"patch"`
+	Todo    *models.Todo `json:"todo"`
+	Changed []string     `json:"changed" xml:"changed>field"`
+}
 
-	updated, err := h.storage.Update(c.Request().Context(), id, &todo)
+// Patch updates only the fields present in the body and reports which of
+// them actually changed, e.g. {"todo": {...}, "changed": ["done"]}.
+func (h *TodoHandler) Patch(c echo.Context) error {
+	id, err := parseID(c)
 	if err != nil {
-		return response.NotFound(c, "Todo not found")
+		return response.BadRequest(c, err.Error())
+	}
+
+	var patch models.TodoPatch
+	if err := c.Bind(&patch); err != nil {
+		return bindError(c, err)
+	}
+	if err := c.Validate(&patch); err != nil {
+		return requestError(c, err)
 	}
 
+	todo, changed, err := h.storage.Patch(c.Request().Context(), id, patch)
+	if err != nil {
+		return response.FromError(c, err)
+	}
+	return response.OK(c, patchResult{Todo: todo, Changed: changed})
+}
+
+func (h *TodoHandler) Toggle(c echo.Context) error {
+	id, err := parseID(c)
+	if err != nil {
+		return response.BadRequest(c, err.Error())
+	}
+
+	updated, err := h.storage.ToggleDone(c.Request().Context(), id)
+	if err != nil {
+		return response.FromError(c, err)
+	}
 	return response.OK(c, updated)
 }
 
-func (h *TodoHandler) Delete(c echo.Context) error {
-	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+// Duplicate creates a copy of :id titled "<title> (copy)", not done, with
+// the same description, and returns it with 201.
+func (h *TodoHandler) Duplicate(c echo.Context) error {
+	id, err := parseID(c)
 	if err != nil {
-		return response.BadRequest(c, "Invalid ID")
+		return response.BadRequest(c, err.Error())
 	}
 
-	err = h.storage.Delete(c.Request().Context(), id)
+	todo, err := h.storage.Duplicate(c.Request().Context(), id)
 	if err != nil {
-		return response.NotFound(c, "Todo not found")
+		return response.FromError(c, err)
+	}
+	return response.Created(c, todo)
+}
+
+type setAllDoneRequest struct {
+	Done *models.Bool `json:"done"`
+}
+
+// dryRunResult previews a bulk change: which todos it would touch.
+type dryRunResult struct {
+	DryRun bool          `json:"dry_run"`
+	Count  int           `json:"count"`
+	IDs    []int64       `json:"ids"`
+	Todos  []models.Todo `json:"todos"`
+}
+
+func newDryRunResult(todos []models.Todo) dryRunResult {
+	ids := make([]int64, 0, len(todos))
+	for _, t := range todos {
+		ids = append(ids, t.ID)
+	}
+	if todos == nil {
+		todos = []models.Todo{}
+	}
+	return dryRunResult{DryRun: true, Count: len(todos), IDs: ids, Todos: todos}
+}
+
+// parseBoolParam reads an optional boolean query flag; absent means false.
+func parseBoolParam(c echo.Context, name string) (bool, error) {
+	v := c.QueryParam(name)
+	if v == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(v)
+}
+
+// SetAllDone marks every todo done or not done ("complete all" / "reopen all").
+// With ?dry_run=true it only reports which todos would change.
+func (h *TodoHandler) SetAllDone(c echo.Context) error {
+	var req setAllDoneRequest
+	if err := c.Bind(&req); err != nil {
+		return bindError(c, err)
+	}
+	if req.Done == nil {
+		return response.BadRequest(c, "done is required")
+	}
+
+	dryRun, err := parseBoolParam(c, "dry_run")
+	if err != nil {
+		return response.BadRequest(c, "dry_run must be true or false")
+	}
+	if dryRun {
+		affected, err := h.storage.PreviewSetAllDone(c.Request().Context(), bool(*req.Done))
+		if err != nil {
+			return response.InternalServerError(c, err)
+		}
+		return response.OK(c, newDryRunResult(affected))
+	}
+
+	updated, err := h.storage.SetAllDone(c.Request().Context(), bool(*req.Done))
+	if err != nil {
+		return response.InternalServerError(c, err)
+	}
+	return response.OK(c, map[string]int64{"updated": updated})
+}
+
+func queryError(c echo.Context, err error) error {
+	var qpErr *queryparams.Error
+	if errors.As(err, &qpErr) {
+		return response.ValidationError(c, "Invalid query parameters", qpErr.Fields)
+	}
+	return response.BadRequest(c, "Invalid query parameters")
+}
+
+func okWithFields(c echo.Context, data any, fields []string) error {
+	selected, err := response.SelectFields(data, fields)
+	if err != nil {
+		return response.InternalServerError(c, err)
+	}
+	return response.OK(c, selected)
+}
+
+func bindError(c echo.Context, err error) error {
+	var bindErr *binder.Error
+	if errors.As(err, &bindErr) {
+		return response.BadRequest(c, bindErr.Message)
+	}
+	return response.BadRequest(c, "Invalid request body")
+}
+
+// requestError turns a bind or validation failure into a 400.
+func requestError(c echo.Context, err error) error {
+	var valErr *validation.Errors
+	if errors.As(err, &valErr) {
+		return response.ValidationError(c, "Validation failed", valErr.Fields)
+	}
+
+	var bindErr *binder.Error
+	if errors.As(err, &bindErr) {
+		return response.BadRequest(c, bindErr.Message)
 	}
-	return response.NoContent(c)
+	// Anything else came from a resource's Validate hook.
+	return response.BadRequest(c, err.Error())
 }
@@ -0,0 +1,369 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/manish-npx/simple-go-echo/internal/config"
+	"github.com/manish-npx/simple-go-echo/internal/http/middlewares"
+	"github.com/manish-npx/simple-go-echo/internal/models"
+	"github.com/manish-npx/simple-go-echo/internal/validation"
+)
+
+// newTodoHandlerServer serves the TodoHandler routes the tests use on
+// store, validating bodies like the app does.
+func newTodoHandlerServer(store *fakeStore) *echo.Echo {
+	e := echo.New()
+	e.Validator = validation.New(validation.Limits{})
+	h := NewTodoHandler(store, config.API{})
+	api := e.Group("/api")
+	api.POST("/todos/create", h.Create)
+	api.POST("/todos/upsert", h.Upsert)
+	api.POST("/todos/batch-ops", h.BatchOps)
+	api.POST("/todos/:id/duplicate", h.Duplicate)
+	return e
+}
+
+// send serves a request with a JSON body.
+func send(e *echo.Echo, method, target, body string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+// slowTodos yields count todos, waiting gap after each like a slow cursor
+// would, and stops with the context's error once it ends.
+func slowTodos(count int, gap time.Duration) func(context.Context, func(*models.Todo) error) error {
+	return func(ctx context.Context, fn func(*models.Todo) error) error {
+		for i := 1; i <= count; i++ {
+			if err := fn(&models.Todo{ID: int64(i), Title: "todo"}); err != nil {
+				return err
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(gap):
+			}
+		}
+		return nil
+	}
+}
+
+// newStreamServer serves a slow stream at /api/todos/stream behind the
+// Timeout middleware, with a 30ms default and the given per-route timeouts.
+func newStreamServer(perRoute map[string]time.Duration) *echo.Echo {
+	e := echo.New()
+	e.Use(middlewares.Timeout(30*time.Millisecond, perRoute))
+	e.GET("/api/todos/stream", func(c echo.Context) error {
+		return streamJSON(c, slowTodos(4, 20*time.Millisecond))
+	})
+	return e
+}
+
+func TestStreamPastDeadlineCompletesWhenExempt(t *testing.T) {
+	// The server registers streaming routes with a zero timeout.
+	e := newStreamServer(map[string]time.Duration{"/api/todos/stream": 0})
+
+	rec := serve(e, http.MethodGet, "/api/todos/stream", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.HasSuffix(body, "]\n") {
+		t.Fatalf("body = %q, want a complete array", body)
+	}
+	var todos []models.Todo
+	if err := json.Unmarshal(rec.Body.Bytes(), &todos); err != nil || len(todos) != 4 {
+		t.Errorf("decoded %d todos (%v), want 4", len(todos), err)
+	}
+}
+
+func TestStreamPastDeadlineAbortsWithTimeout(t *testing.T) {
+	e := newStreamServer(nil)
+	rec := httptest.NewRecorder()
+
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler", r)
+		}
+		if strings.HasSuffix(rec.Body.String(), "]\n") {
+			t.Errorf("body = %q, want it cut off", rec.Body)
+		}
+	}()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/todos/stream", nil))
+}
+
+func TestStreamEmpty(t *testing.T) {
+	e := echo.New()
+	e.GET("/api/todos/stream", func(c echo.Context) error {
+		return streamJSON(c, slowTodos(0, 0))
+	})
+	rec := serve(e, http.MethodGet, "/api/todos/stream", nil)
+	if rec.Code != http.StatusOK || rec.Body.String() != "[]\n" {
+		t.Errorf("got %d %q, want 200 []", rec.Code, rec.Body)
+	}
+}
+
+// discardWriter is a ResponseWriter that only counts the body, so a large
+// stream can go through without being buffered by the test.
+type discardWriter struct {
+	header http.Header
+	code   int
+	n      int
+	tail   string
+}
+
+func (w *discardWriter) Header() http.Header  { return w.header }
+func (w *discardWriter) WriteHeader(code int) { w.code = code }
+
+func (w *discardWriter) Write(p []byte) (int, error) {
+	w.n += len(p)
+	w.tail = string(p[max(0, len(p)-2):])
+	return len(p), nil
+}
+
+// heapAlloc returns the live heap after a collection.
+func heapAlloc() uint64 {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.HeapAlloc
+}
+
+func TestStreamMemoryStaysFlat(t *testing.T) {
+	const (
+		rows   = 100_000
+		warmUp = 1_000
+	)
+	var before, after uint64
+	each := func(ctx context.Context, fn func(*models.Todo) error) error {
+		for i := 1; i <= rows; i++ {
+			if err := fn(&models.Todo{ID: int64(i), Title: "todo", CreatedAt: time.Now()}); err != nil {
+				return err
+			}
+			switch i {
+			case warmUp:
+				before = heapAlloc()
+			case rows:
+				after = heapAlloc()
+			}
+		}
+		return nil
+	}
+
+	e := echo.New()
+	e.GET("/api/todos/stream", func(c echo.Context) error {
+		return streamJSON(c, each)
+	})
+	w := &discardWriter{header: http.Header{}}
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/todos/stream", nil))
+
+	if w.code != http.StatusOK || w.tail != "]\n" || w.n < rows*50 {
+		t.Fatalf("got %d, %d bytes ending %q; want a complete 200 array", w.code, w.n, w.tail)
+	}
+	// Keeping the other 99k todos or their encoding would take over 10MB.
+	if growth := int64(after) - int64(before); growth > 1<<20 {
+		t.Errorf("heap grew by %d bytes over %d rows, want it flat", growth, rows-warmUp)
+	}
+}
+
+func TestCreateIfNoneMatch(t *testing.T) {
+	ext := "ext-1"
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		body        string
+		code        int
+	}{
+		{"absent", "*", `{"title": "Walk dog"}`, http.StatusCreated},
+		{"title exists", "*", `{"title": "buy MILK"}`, http.StatusPreconditionFailed},
+		{"external_id exists", "*", `{"title": "Walk dog", "external_id": "ext-1"}`, http.StatusPreconditionFailed},
+		{"new external_id, same title", "*", `{"title": "Buy milk", "external_id": "ext-2"}`, http.StatusCreated},
+		{"other values ignored", `"abc"`, `{"title": "Buy milk"}`, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeStore{todos: []models.Todo{{ID: 1, Title: "Buy milk", ExternalID: &ext}}}
+			e := newTodoHandlerServer(store)
+
+			rec := send(e, http.MethodPost, "/api/todos/create", tt.body, map[string]string{"If-None-Match": tt.ifNoneMatch})
+			if rec.Code != tt.code {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.code, rec.Body)
+			}
+			if tt.code == http.StatusCreated {
+				if len(store.todos) != 2 {
+					t.Errorf("store has %d todos, want the new one added", len(store.todos))
+				}
+				return
+			}
+
+			var body map[string]string
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body["code"] != "PRECONDITION_FAILED" || !strings.Contains(body["error"], "already exists") {
+				t.Errorf("body = %v, want a PRECONDITION_FAILED envelope", body)
+			}
+			if len(store.todos) != 1 {
+				t.Errorf("store has %d todos, want nothing created", len(store.todos))
+			}
+		})
+	}
+}
+
+func TestUpsertCreatesThenUpdates(t *testing.T) {
+	store := &fakeStore{}
+	e := newTodoHandlerServer(store)
+
+	rec := send(e, http.MethodPost, "/api/todos/upsert", `{"title": "Draft", "external_id": "ext-1"}`, nil)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("first upsert: status = %d, want 201: %s", rec.Code, rec.Body)
+	}
+	rec = send(e, http.MethodPost, "/api/todos/upsert", `{"title": "Final", "external_id": "ext-1"}`, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("second upsert: status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+
+	var todo models.Todo
+	if err := json.Unmarshal(rec.Body.Bytes(), &todo); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.todos) != 1 || todo.ID != 1 || todo.Title != "Final" {
+		t.Errorf("got %+v with %d todos stored, want todo 1 updated", todo, len(store.todos))
+	}
+}
+
+func TestUpsertRequiresExternalID(t *testing.T) {
+	rec := send(newTodoHandlerServer(&fakeStore{}), http.MethodPost, "/api/todos/upsert", `{"title": "Draft"}`, nil)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "external_id") {
+		t.Errorf("got %d %s, want 400 naming external_id", rec.Code, rec.Body)
+	}
+}
+
+func TestBatchOpsReportsEveryInvalidOp(t *testing.T) {
+	// Ops 1, 2 and 4 are invalid; ApplyOps would panic on the fake if the
+	// batch got that far.
+	const body = `[
+		{"op": "create", "todo": {"title": "ok"}},
+		{"op": "create", "todo": {"title": ""}},
+		{"op": "delete"},
+		{"op": "update", "id": 1, "todo": {"title": "ok"}},
+		{"op": "rename", "id": 1}
+	]`
+	e := newTodoHandlerServer(&fakeStore{})
+
+	t.Run("JSON", func(t *testing.T) {
+		rec := send(e, http.MethodPost, "/api/todos/batch-ops", body, nil)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("status = %d, want 400: %s", rec.Code, rec.Body)
+		}
+		var got struct {
+			Error  string `json:"error"`
+			Errors []struct {
+				Index  int               `json:"index"`
+				Error  string            `json:"error"`
+				Fields map[string]string `json:"fields"`
+			} `json:"errors"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Error != "Validation failed" || len(got.Errors) != 3 {
+			t.Fatalf("body = %s, want 3 errors", rec.Body)
+		}
+		want := []struct {
+			index int
+			error string
+			field string
+		}{
+			{1, "", "title"},
+			{2, "id must be a positive integer", ""},
+			{4, "op must be one of create, update, delete", ""},
+		}
+		for i, w := range want {
+			item := got.Errors[i]
+			if item.Index != w.index || item.Error != w.error || (w.field != "" && item.Fields[w.field] == "") {
+				t.Errorf("errors[%d] = %+v, want index %d (error %q, field %q)", i, item, w.index, w.error, w.field)
+			}
+		}
+	})
+
+	t.Run("XML", func(t *testing.T) {
+		rec := send(e, http.MethodPost, "/api/todos/batch-ops", body, map[string]string{echo.HeaderAccept: echo.MIMEApplicationXML})
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("status = %d, want 400: %s", rec.Code, rec.Body)
+		}
+		var got struct {
+			Error   string `xml:"error"`
+			Indexes []int  `xml:"errors>item>index"`
+		}
+		if err := xml.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("%v in %s", err, rec.Body)
+		}
+		if got.Error != "Validation failed" || !slices.Equal(got.Indexes, []int{1, 2, 4}) {
+			t.Errorf("body = %s, want indexes 1, 2 and 4", rec.Body)
+		}
+	})
+}
+
+func TestDuplicateEndpoint(t *testing.T) {
+	desc := "two litres"
+	store := &fakeStore{todos: []models.Todo{{ID: 1, Title: "Buy milk", Description: &desc, Done: true}}}
+	e := newTodoHandlerServer(store)
+
+	rec := send(e, http.MethodPost, "/api/todos/1/duplicate", "", nil)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", rec.Code, rec.Body)
+	}
+	var clone models.Todo
+	if err := json.Unmarshal(rec.Body.Bytes(), &clone); err != nil {
+		t.Fatal(err)
+	}
+	if clone.ID == 1 || clone.Title != "Buy milk (copy)" || clone.Description == nil || *clone.Description != desc || clone.Done {
+		t.Errorf("clone = %+v, want a new, not done copy", clone)
+	}
+
+	if rec := send(e, http.MethodPost, "/api/todos/9/duplicate", "", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("missing id: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestCreateCheckDuplicate(t *testing.T) {
+	tests := []struct {
+		title  string
+		code   int
+		stored int
+	}{
+		{"buy MILK", http.StatusConflict, 1},
+		{"Buy milk", http.StatusConflict, 1},
+		{"Buy bread", http.StatusCreated, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			store := &fakeStore{todos: []models.Todo{{ID: 1, Title: "Buy milk"}}}
+			e := newTodoHandlerServer(store)
+
+			rec := send(e, http.MethodPost, "/api/todos/create?check_duplicate=true", `{"title": "`+tt.title+`"}`, nil)
+			if rec.Code != tt.code {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.code, rec.Body)
+			}
+			if len(store.todos) != tt.stored {
+				t.Errorf("store has %d todos, want %d", len(store.todos), tt.stored)
+			}
+		})
+	}
+}
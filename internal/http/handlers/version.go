@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/manish-npx/simple-go-echo/internal/utils/response"
+)
+
+// BuildInfo identifies the running binary. The fields are stamped into the
+// main package at build time with -ldflags.
+type BuildInfo struct {
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+// Version reports which build is serving, to confirm a deploy took effect.
+func Version(info BuildInfo) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return response.OK(c, info)
+	}
+}
@@ -0,0 +1,60 @@
+package middlewares
+
+import (
+	"slices"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/manish-npx/simple-go-echo/internal/logger"
+)
+
+// AccessLog writes one structured line per request, with a coarse latency
+// bucket so slow endpoints stand out without a metrics stack. Requests to
+// the skip paths (health probes, metrics) are not logged.
+func AccessLog(skip []string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if slices.Contains(skip, req.URL.Path) {
+				return next(c)
+			}
+
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				// Let the error handler write the response so we log the
+				// real status and size.
+				c.Error(err)
+			}
+			latency := time.Since(start)
+
+			res := c.Response()
+			logger.FromContext(req.Context()).Info("request",
+				"method", req.Method,
+				"route", c.Path(),
+				"uri", req.RequestURI,
+				"status", res.Status,
+				"latency", latency,
+				"latency_bucket", LatencyBucket(latency),
+				"bytes_in", req.ContentLength,
+				"bytes_out", res.Size,
+				"remote_ip", c.RealIP(),
+			)
+			return nil
+		}
+	}
+}
+
+// LatencyBucket labels a duration as <10ms, <100ms, <1s or >1s.
+func LatencyBucket(d time.Duration) string {
+	switch {
+	case d < 10*time.Millisecond:
+		return "<10ms"
+	case d < 100*time.Millisecond:
+		return "<100ms"
+	case d < time.Second:
+		return "<1s"
+	default:
+		return ">1s"
+	}
+}
@@ -0,0 +1,66 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/manish-npx/simple-go-echo/internal/config"
+	"github.com/manish-npx/simple-go-echo/internal/errs"
+	"github.com/manish-npx/simple-go-echo/internal/utils/response"
+)
+
+// testKeyDigest is the SHA-256 of "test".
+const testKeyDigest = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+func TestAPIKeyAuth(t *testing.T) {
+	e := echo.New()
+	e.HTTPErrorHandler = response.CustomErrorHandler
+	g := e.Group("", APIKeyAuth([]config.APIKey{{Label: "billing", SHA256: testKeyDigest}}))
+	g.GET("/todos", func(c echo.Context) error {
+		return c.String(http.StatusOK, c.Get(APIKeyLabelKey).(string))
+	})
+	// A handler refusing an authenticated caller, as a per-key permission
+	// check would.
+	g.GET("/admin", func(c echo.Context) error {
+		return errs.Forbidden("Key " + c.Get(APIKeyLabelKey).(string) + " may not access this")
+	})
+
+	tests := []struct {
+		name      string
+		path, key string
+		code      int
+		body      string
+		challenge bool
+	}{
+		{"missing key", "/todos", "", http.StatusUnauthorized, `{"code":"UNAUTHORIZED","error":"Missing API key"}`, true},
+		{"invalid key", "/todos", "wrong", http.StatusUnauthorized, `{"code":"UNAUTHORIZED","error":"Invalid API key"}`, true},
+		{"valid key", "/todos", "test", http.StatusOK, "billing", false},
+		{"valid key, wrong caller", "/admin", "test", http.StatusForbidden, `{"code":"FORBIDDEN","error":"Key billing may not access this"}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.key != "" {
+				req.Header.Set(HeaderAPIKey, tt.key)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.code {
+				t.Errorf("status = %d, want %d", rec.Code, tt.code)
+			}
+			if got := rec.Body.String(); got != tt.body && got != tt.body+"\n" {
+				t.Errorf("body = %s, want %s", got, tt.body)
+			}
+			challenge := rec.Header().Get(echo.HeaderWWWAuthenticate)
+			if tt.challenge && challenge != `APIKey header="X-API-Key"` {
+				t.Errorf("WWW-Authenticate = %q, want the APIKey challenge", challenge)
+			}
+			if !tt.challenge && challenge != "" {
+				t.Errorf("WWW-Authenticate = %q, want none", challenge)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/manish-npx/simple-go-echo/internal/logger"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are matched case-insensitively as substrings of JSON keys,
+// so "password", "new_password" and "accessToken" are all redacted.
+var sensitiveKeys = []string{"password", "token", "secret", "api_key", "apikey", "authorization"}
+
+// BodyLog logs request and response bodies for reproducing client bugs.
+// It logs at info level, since enabling it is already the opt-in, so it
+// works without also lowering log.level. Sensitive JSON fields are redacted
+// and each body is cut to maxBytes. Never enable it in production.
+func BodyLog(maxBytes int) echo.MiddlewareFunc {
+	return middleware.BodyDumpWithConfig(middleware.BodyDumpConfig{
+		Handler: func(c echo.Context, reqBody, resBody []byte) {
+			logger.FromContext(c.Request().Context()).Info("request bodies",
+				"method", c.Request().Method,
+				"uri", c.Request().RequestURI,
+				"request_body", redactBody(reqBody, maxBytes),
+				"response_body", redactBody(resBody, maxBytes),
+			)
+		},
+	})
+}
+
+func redactBody(body []byte, maxBytes int) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var v any
+	if err := json.Unmarshal(body, &v); err == nil {
+		if out, err := json.Marshal(redact(v)); err == nil {
+			body = out
+		}
+	}
+
+	if maxBytes > 0 && len(body) > maxBytes {
+		return string(body[:maxBytes]) + "...(truncated)"
+	}
+	return string(body)
+}
+
+func redact(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			if isSensitive(k) {
+				v[k] = redacted
+			} else {
+				v[k] = redact(val)
+			}
+		}
+	case []any:
+		for i, val := range v {
+			v[i] = redact(val)
+		}
+	}
+	return v
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
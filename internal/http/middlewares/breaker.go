@@ -0,0 +1,23 @@
+package middlewares
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/manish-npx/simple-go-echo/internal/database"
+	"github.com/manish-npx/simple-go-echo/internal/utils/response"
+)
+
+// CircuitBreaker answers with 503 straight away while the database breaker
+// is open, instead of letting every request wait for a query timeout. With
+// tenancy it checks the tenant's breaker (see database.BreakerFor) and
+// must run after Tenant; fallback is the main database's.
+func CircuitBreaker(fallback *database.Breaker) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			b := database.BreakerFor(c.Request().Context(), fallback)
+			if !b.Allow() {
+				return response.ServiceUnavailable(c, "Database unavailable, please retry later", b.Cooldown())
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// DefaultCacheControl is sent whenever no policy applies, so nothing is
+// cached by accident.
+const DefaultCacheControl = "no-store"
+
+// CacheControl sets Cache-Control on every response whose handler didn't
+// set one itself. Successful GET and HEAD responses get perRoute's policy
+// for the matched route, keyed by its registered path such as
+// "/api/todos/:id" (e.g. "private, max-age=30"). Writes, errors and routes
+// without a policy get DefaultCacheControl.
+func CacheControl(perRoute map[string]string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			res := c.Response()
+			res.Before(func() {
+				h := res.Header()
+				if h.Get(echo.HeaderCacheControl) != "" {
+					return
+				}
+				policy := DefaultCacheControl
+				if m := c.Request().Method; (m == http.MethodGet || m == http.MethodHead) && res.Status < http.StatusBadRequest {
+					if p, ok := perRoute[c.Path()]; ok {
+						policy = p
+					}
+				}
+				h.Set(echo.HeaderCacheControl, policy)
+			})
+			return next(c)
+		}
+	}
+}
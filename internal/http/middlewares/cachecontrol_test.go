@@ -0,0 +1,57 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestCacheControl(t *testing.T) {
+	e := echo.New()
+	e.Use(CacheControl(map[string]string{
+		"/todos/:id": "private, max-age=30",
+		"/todos":     "public, max-age=5",
+	}))
+	e.GET("/todos/:id", func(c echo.Context) error {
+		if c.Param("id") == "0" {
+			return c.NoContent(http.StatusNotFound)
+		}
+		return c.String(http.StatusOK, "todo")
+	})
+	e.HEAD("/todos/:id", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	e.PUT("/todos/:id", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	e.GET("/todos", func(c echo.Context) error {
+		// Handlers with their own caching, like api.cache_max_age, win.
+		c.Response().Header().Set(echo.HeaderCacheControl, "max-age=60")
+		return c.NoContent(http.StatusOK)
+	})
+	e.GET("/health", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	tests := []struct {
+		method, target string
+		want           string
+	}{
+		{http.MethodGet, "/todos/1", "private, max-age=30"},
+		{http.MethodHead, "/todos/1", "private, max-age=30"},
+		{http.MethodGet, "/todos/0", DefaultCacheControl},
+		{http.MethodPut, "/todos/1", DefaultCacheControl},
+		{http.MethodGet, "/todos", "max-age=60"},
+		{http.MethodGet, "/health", DefaultCacheControl},
+		{http.MethodGet, "/nope", DefaultCacheControl},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+		if got := rec.Header().Get(echo.HeaderCacheControl); got != tt.want {
+			t.Errorf("%s %s: Cache-Control = %q, want %q", tt.method, tt.target, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package middlewares
+
+import (
+	"expvar"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/manish-npx/simple-go-echo/internal/utils/response"
+)
+
+// concurrencyRetryAfter is the Retry-After hint sent with a rejection.
+const concurrencyRetryAfter = time.Second
+
+var (
+	inflightRequests = expvar.NewInt("http_inflight_requests")
+	rejectedRequests = expvar.NewInt("http_rejected_requests")
+)
+
+// ConcurrencyLimit caps the number of requests handled at once. Requests
+// over the cap get a 503 immediately rather than queueing for a database
+// connection. The in-flight count is published as http_inflight_requests.
+func ConcurrencyLimit(limit int) echo.MiddlewareFunc {
+	sem := make(chan struct{}, limit)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			select {
+			case sem <- struct{}{}:
+			default:
+				rejectedRequests.Add(1)
+				return response.ServiceUnavailable(c, "Server busy, please retry later", concurrencyRetryAfter)
+			}
+			inflightRequests.Add(1)
+			defer func() {
+				inflightRequests.Add(-1)
+				<-sem
+			}()
+
+			return next(c)
+		}
+	}
+}
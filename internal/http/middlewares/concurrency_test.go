@@ -0,0 +1,70 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestConcurrencyLimitSaturated(t *testing.T) {
+	const limit = 2
+	entered := make(chan struct{})
+	release := make(chan struct{})
+
+	e := echo.New()
+	e.Use(ConcurrencyLimit(limit))
+	e.GET("/slow", func(c echo.Context) error {
+		entered <- struct{}{}
+		<-release
+		return c.NoContent(http.StatusNoContent)
+	})
+	e.GET("/fast", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	inflightBefore := inflightRequests.Value()
+	rejectedBefore := rejectedRequests.Value()
+
+	var wg sync.WaitGroup
+	for range limit {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if rec := get("/slow"); rec.Code != http.StatusNoContent {
+				t.Errorf("slow request: status %d, want 204", rec.Code)
+			}
+		}()
+		<-entered
+	}
+
+	if got := inflightRequests.Value() - inflightBefore; got != limit {
+		t.Errorf("http_inflight_requests rose by %d, want %d", got, limit)
+	}
+	rec := get("/fast")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("over the limit: status %d, want 503", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want 1", got)
+	}
+	if got := rejectedRequests.Value() - rejectedBefore; got != 1 {
+		t.Errorf("http_rejected_requests rose by %d, want 1", got)
+	}
+
+	close(release)
+	wg.Wait()
+	if rec := get("/fast"); rec.Code != http.StatusNoContent {
+		t.Errorf("after release: status %d, want 204", rec.Code)
+	}
+	if got := inflightRequests.Value(); got != inflightBefore {
+		t.Errorf("http_inflight_requests = %d, want %d", got, inflightBefore)
+	}
+}
@@ -0,0 +1,54 @@
+package middlewares
+
+import (
+	"github.com/labstack/echo/v4"
+)
+
+// HeaderXCorrelationID is the gateway's trace header.
+const HeaderXCorrelationID = "X-Correlation-ID"
+
+// maxRequestIDLen bounds incoming IDs; anything longer is replaced.
+const maxRequestIDLen = 128
+
+// CorrelationID lets an upstream ID become the request ID, so traces stay
+// continuous across services. It prefers X-Correlation-ID, then
+// X-Request-ID; echo's RequestID middleware, which must run next, only
+// generates a fresh ID when neither is usable. The ID is echoed back in
+// both headers.
+func CorrelationID() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			h := c.Request().Header
+
+			id := h.Get(HeaderXCorrelationID)
+			if !validRequestID(id) {
+				id = h.Get(echo.HeaderXRequestID)
+			}
+			if validRequestID(id) {
+				h.Set(echo.HeaderXRequestID, id)
+			} else {
+				h.Del(echo.HeaderXRequestID)
+			}
+
+			c.Response().Before(func() {
+				res := c.Response().Header()
+				res.Set(HeaderXCorrelationID, res.Get(echo.HeaderXRequestID))
+			})
+			return next(c)
+		}
+	}
+}
+
+// validRequestID accepts printable ASCII without spaces, so a client can't
+// inject log lines or oversized values.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
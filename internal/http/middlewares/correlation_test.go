@@ -0,0 +1,58 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+func TestCorrelationID(t *testing.T) {
+	e := echo.New()
+	e.Use(CorrelationID(), middleware.RequestID())
+	var seen string
+	e.GET("/", func(c echo.Context) error {
+		seen = c.Response().Header().Get(echo.HeaderXRequestID)
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		want   string // "" means a freshly generated ID
+	}{
+		{"no header", nil, ""},
+		{"correlation ID", map[string]string{HeaderXCorrelationID: "trace-123"}, "trace-123"},
+		{"request ID", map[string]string{echo.HeaderXRequestID: "req-456"}, "req-456"},
+		{"correlation ID wins", map[string]string{HeaderXCorrelationID: "trace-123", echo.HeaderXRequestID: "req-456"}, "trace-123"},
+		{"invalid falls back", map[string]string{HeaderXCorrelationID: "has space", echo.HeaderXRequestID: "req-456"}, "req-456"},
+		{"too long is replaced", map[string]string{HeaderXCorrelationID: strings.Repeat("a", maxRequestIDLen+1)}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			id := rec.Header().Get(echo.HeaderXRequestID)
+			if tt.want != "" && id != tt.want {
+				t.Errorf("X-Request-ID = %q, want %q", id, tt.want)
+			}
+			if tt.want == "" && (id == "" || id == tt.header[HeaderXCorrelationID]) {
+				t.Errorf("X-Request-ID = %q, want a generated ID", id)
+			}
+			if got := rec.Header().Get(HeaderXCorrelationID); got != id {
+				t.Errorf("X-Correlation-ID = %q, want %q", got, id)
+			}
+			if seen != id {
+				t.Errorf("handler saw request ID %q, want %q", seen, id)
+			}
+		})
+	}
+}
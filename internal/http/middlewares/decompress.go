@@ -0,0 +1,47 @@
+package middlewares
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/manish-npx/simple-go-echo/internal/utils/response"
+)
+
+// Decompress inflates request bodies sent with Content-Encoding: gzip, so
+// handlers and the binder see plain JSON. The inflated body is capped at
+// maxBytes to defuse zip bombs: larger bodies get a 413, corrupt gzip a 400.
+func Decompress(maxBytes int64) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if !strings.EqualFold(req.Header.Get(echo.HeaderContentEncoding), "gzip") {
+				return next(c)
+			}
+
+			gz, err := gzip.NewReader(req.Body)
+			if err != nil {
+				return response.BadRequest(c, "Malformed gzip body")
+			}
+			defer gz.Close()
+
+			body, err := io.ReadAll(io.LimitReader(gz, maxBytes+1))
+			if err != nil {
+				return response.BadRequest(c, "Malformed gzip body")
+			}
+			if int64(len(body)) > maxBytes {
+				return response.PayloadTooLarge(c, fmt.Sprintf("Decompressed body exceeds %d bytes", maxBytes))
+			}
+
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			req.ContentLength = int64(len(body))
+			req.Header.Del(echo.HeaderContentEncoding)
+			req.Header.Set(echo.HeaderContentLength, strconv.Itoa(len(body)))
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package middlewares
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func gzipped(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecompress(t *testing.T) {
+	const maxBytes = 64
+	e := echo.New()
+	e.Use(Decompress(maxBytes))
+	e.POST("/", func(c echo.Context) error {
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, c.Request().Header.Get(echo.HeaderContentEncoding)+"|"+string(body))
+	})
+
+	tests := []struct {
+		name     string
+		body     []byte
+		encoding string
+		code     int
+		want     string
+	}{
+		{"gzip", gzipped(t, `{"title":"x"}`), "gzip", http.StatusOK, `|{"title":"x"}`},
+		{"gzip any case", gzipped(t, `{}`), "GZIP", http.StatusOK, `|{}`},
+		{"plain passes through", []byte(`{}`), "", http.StatusOK, `|{}`},
+		{"exactly at the limit", gzipped(t, strings.Repeat("a", maxBytes)), "gzip", http.StatusOK, "|" + strings.Repeat("a", maxBytes)},
+		{"not gzip", []byte("plain text"), "gzip", http.StatusBadRequest, "Malformed gzip body"},
+		{"truncated", gzipped(t, strings.Repeat("a", 32))[:20], "gzip", http.StatusBadRequest, "Malformed gzip body"},
+		{"too large", gzipped(t, strings.Repeat("a", maxBytes+1)), "gzip", http.StatusRequestEntityTooLarge, "Decompressed body exceeds 64 bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.body))
+			if tt.encoding != "" {
+				req.Header.Set(echo.HeaderContentEncoding, tt.encoding)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.code {
+				t.Errorf("status = %d, want %d", rec.Code, tt.code)
+			}
+			if !strings.Contains(rec.Body.String(), tt.want) {
+				t.Errorf("body = %s, want %s", rec.Body, tt.want)
+			}
+		})
+	}
+}
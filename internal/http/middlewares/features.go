@@ -0,0 +1,32 @@
+package middlewares
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/manish-npx/simple-go-echo/internal/features"
+)
+
+// Features puts the flags in every request's context, so handlers can call
+// features.Enabled.
+func Features(flags features.Flags) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			c.SetRequest(req.WithContext(features.NewContext(req.Context(), flags)))
+			return next(c)
+		}
+	}
+}
+
+// RequireFeature hides a route or group while the named feature is off: it
+// answers exactly like a path with no route, so disabled endpoints don't
+// reveal that they exist.
+func RequireFeature(name string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !features.Enabled(c.Request().Context(), name) {
+				return echo.ErrNotFound
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package middlewares
+
+import (
+	"regexp"
+
+	"github.com/labstack/echo/v4"
+	"github.com/manish-npx/simple-go-echo/internal/config"
+	"github.com/manish-npx/simple-go-echo/internal/utils/response"
+)
+
+type requiredHeader struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// RequireHeaders rejects requests that lack one of the configured headers,
+// or whose value doesn't match its pattern, with a 400 naming the header.
+// Patterns are validated by config.Load, so compiling them cannot fail.
+func RequireHeaders(headers []config.RequiredHeader) echo.MiddlewareFunc {
+	rules := make([]requiredHeader, 0, len(headers))
+	for _, h := range headers {
+		r := requiredHeader{name: h.Name}
+		if h.Pattern != "" {
+			r.pattern = regexp.MustCompile(h.Pattern)
+		}
+		rules = append(rules, r)
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			for _, r := range rules {
+				v := c.Request().Header.Get(r.name)
+				if v == "" {
+					return response.BadRequest(c, "Missing required header "+r.name)
+				}
+				if r.pattern != nil && !r.pattern.MatchString(v) {
+					return response.BadRequest(c, "Invalid value for header "+r.name)
+				}
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/manish-npx/simple-go-echo/internal/config"
+)
+
+func TestRequireHeaders(t *testing.T) {
+	e := echo.New()
+	e.Use(RequireHeaders([]config.RequiredHeader{
+		{Name: "X-Tenant-ID", Pattern: `^[a-z0-9-]+$`},
+		{Name: "X-Client"},
+	}))
+	e.GET("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		code   int
+		want   string
+	}{
+		{"present", map[string]string{"X-Tenant-ID": "acme", "X-Client": "web"}, http.StatusNoContent, ""},
+		{"absent", map[string]string{"X-Client": "web"}, http.StatusBadRequest, `{"error":"Missing required header X-Tenant-ID"}`},
+		{"absent without pattern", map[string]string{"X-Tenant-ID": "acme"}, http.StatusBadRequest, `{"error":"Missing required header X-Client"}`},
+		{"invalid", map[string]string{"X-Tenant-ID": "Acme Corp", "X-Client": "web"}, http.StatusBadRequest, `{"error":"Invalid value for header X-Tenant-ID"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.code {
+				t.Errorf("status = %d, want %d", rec.Code, tt.code)
+			}
+			if tt.want != "" && rec.Body.String() != tt.want+"\n" {
+				t.Errorf("body = %s, want %s", rec.Body, tt.want)
+			}
+		})
+	}
+}
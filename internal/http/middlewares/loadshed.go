@@ -0,0 +1,62 @@
+package middlewares
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/echo/v4"
+	"github.com/manish-npx/simple-go-echo/internal/database"
+	"github.com/manish-npx/simple-go-echo/internal/utils/response"
+)
+
+// loadShedRetryAfter is the Retry-After hint sent with a shed request.
+const loadShedRetryAfter = time.Second
+
+var shedRequests = expvar.NewInt("http_shed_requests")
+
+// LoadShed rejects requests with a 503 while the database pool is
+// saturated: no idle connections, every connection acquired, and at least
+// threshold more requests in progress than there are connections, i.e.
+// roughly that many already waiting for one. pgxpool does not expose its
+// wait queue, so the requests seen by this middleware stand in for it.
+// With tenancy it must run after Tenant, and each tenant's pool is judged
+// on its own requests; pool is the main database's. Rejections are counted
+// in http_shed_requests.
+func LoadShed(pool *pgxpool.Pool, threshold int) echo.MiddlewareFunc {
+	return loadShed(threshold, func(ctx context.Context) poolLoad {
+		stat := database.PoolFor(ctx, pool).Stat()
+		return poolLoad{idle: stat.IdleConns(), acquired: stat.AcquiredConns(), max: stat.MaxConns()}
+	})
+}
+
+// poolLoad is the part of pgxpool.Stat LoadShed looks at.
+type poolLoad struct {
+	idle, acquired, max int32
+}
+
+// loadShed is LoadShed reading the load of ctx's pool from load.
+func loadShed(threshold int, load func(ctx context.Context) poolLoad) echo.MiddlewareFunc {
+	// In-flight requests per tenant, "" being the main database. Keyed by
+	// name rather than pool, so reopened tenant pools don't add entries.
+	var inflight sync.Map
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := c.Request().Context()
+			counter, _ := inflight.LoadOrStore(database.Tenant(ctx), new(atomic.Int64))
+			n := counter.(*atomic.Int64).Add(1)
+			defer counter.(*atomic.Int64).Add(-1)
+
+			stat := load(ctx)
+			waiting := n - 1 - int64(stat.acquired)
+			if stat.idle == 0 && stat.acquired >= stat.max && waiting >= int64(threshold) {
+				shedRequests.Add(1)
+				return response.ServiceUnavailable(c, "Server overloaded, please retry later", loadShedRetryAfter)
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestLoadShedExhaustedPool(t *testing.T) {
+	const threshold = 1
+	entered := make(chan struct{})
+	release := make(chan struct{})
+
+	// The pool's single connection is taken and none is idle.
+	load := func(context.Context) poolLoad { return poolLoad{idle: 0, acquired: 1, max: 1} }
+
+	e := echo.New()
+	e.GET("/health", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+	api := e.Group("/api", loadShed(threshold, load))
+	api.GET("/slow", func(c echo.Context) error {
+		entered <- struct{}{}
+		<-release
+		return c.NoContent(http.StatusNoContent)
+	})
+	api.GET("/fast", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	shedBefore := shedRequests.Value()
+
+	// One request holds the connection and threshold more wait for it.
+	var wg sync.WaitGroup
+	for range 1 + threshold {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if rec := get("/api/slow"); rec.Code != http.StatusNoContent {
+				t.Errorf("slow request: status %d, want 204", rec.Code)
+			}
+		}()
+		<-entered
+	}
+
+	rec := get("/api/fast")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("exhausted pool: status %d, want 503", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want 1", got)
+	}
+	if got := shedRequests.Value() - shedBefore; got != 1 {
+		t.Errorf("http_shed_requests rose by %d, want 1", got)
+	}
+	if rec := get("/health"); rec.Code != http.StatusNoContent {
+		t.Errorf("outside /api: status %d, want 204", rec.Code)
+	}
+
+	close(release)
+	wg.Wait()
+	// Still saturated, but with the waiters gone nobody is queued.
+	if rec := get("/api/fast"); rec.Code != http.StatusNoContent {
+		t.Errorf("after release: status %d, want 204", rec.Code)
+	}
+	if got := shedRequests.Value() - shedBefore; got != 1 {
+		t.Errorf("http_shed_requests rose by %d, want still 1", got)
+	}
+}
+
+func TestLoadShedBelowThreshold(t *testing.T) {
+	// Saturated, but nothing else is in flight, so nobody is waiting yet.
+	load := func(context.Context) poolLoad { return poolLoad{idle: 0, acquired: 1, max: 1} }
+	e := echo.New()
+	e.GET("/api/fast", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}, loadShed(1, load))
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/fast", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("status %d, want 204", rec.Code)
+	}
+}
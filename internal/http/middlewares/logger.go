@@ -0,0 +1,19 @@
+package middlewares
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/manish-npx/simple-go-echo/internal/logger"
+)
+
+// ContextLogger stores a request-scoped logger, tagged with the request ID,
+// in the request context. It must run after the RequestID middleware.
+func ContextLogger(log logger.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			l := log.With("request_id", c.Response().Header().Get(echo.HeaderXRequestID))
+			req := c.Request()
+			c.SetRequest(req.WithContext(logger.NewContext(req.Context(), l)))
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package middlewares
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/manish-npx/simple-go-echo/internal/utils/response"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// PublicID makes the :id path parameter a UUID (api.id_type: uuid). A
+// malformed one gets a 400 without touching the database; a well-formed
+// one is looked up with resolve and replaced by the integer id, so
+// handlers keep parsing integers. Errors from resolve, such as not found,
+// go through response.FromError. Routes without :id are left alone.
+func PublicID(resolve func(ctx context.Context, publicID string) (int64, error)) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			i := slices.Index(c.ParamNames(), "id")
+			if i < 0 {
+				return next(c)
+			}
+			values := slices.Clone(c.ParamValues())
+			if !uuidPattern.MatchString(values[i]) {
+				return response.BadRequest(c, "Invalid ID: must be a UUID")
+			}
+			id, err := resolve(c.Request().Context(), values[i])
+			if err != nil {
+				return response.FromError(c, err)
+			}
+			values[i] = strconv.FormatInt(id, 10)
+			c.SetParamValues(values...)
+			return next(c)
+		}
+	}
+}
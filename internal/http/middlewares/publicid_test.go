@@ -0,0 +1,54 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/manish-npx/simple-go-echo/internal/errs"
+	"github.com/manish-npx/simple-go-echo/internal/utils/response"
+)
+
+const knownUUID = "6f1c2b0e-3d4a-4e5f-8a9b-0c1d2e3f4a5b"
+
+func TestPublicID(t *testing.T) {
+	resolve := func(_ context.Context, publicID string) (int64, error) {
+		if publicID == knownUUID {
+			return 42, nil
+		}
+		return 0, errs.NotFound("Todo not found")
+	}
+	e := echo.New()
+	e.HTTPErrorHandler = response.CustomErrorHandler
+	g := e.Group("", PublicID(resolve))
+	g.GET("/todos/:id", func(c echo.Context) error {
+		return c.String(http.StatusOK, c.Param("id"))
+	})
+	g.GET("/todos", func(c echo.Context) error {
+		return c.String(http.StatusOK, "list")
+	})
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/todos/" + knownUUID, http.StatusOK, "42"},
+		{"/todos/1", http.StatusBadRequest, `{"error":"Invalid ID: must be a UUID"}`},
+		{"/todos/6f1c2b0e-3d4a-4e5f-8a9b-0c1d2e3f4a5", http.StatusBadRequest, `{"error":"Invalid ID: must be a UUID"}`},
+		{"/todos/00000000-0000-0000-0000-000000000000", http.StatusNotFound, `{"error":"Todo not found"}`},
+		{"/todos", http.StatusOK, "list"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.code {
+			t.Errorf("GET %s: status %d, want %d", tt.path, rec.Code, tt.code)
+		}
+		if got := rec.Body.String(); got != tt.body && got != tt.body+"\n" {
+			t.Errorf("GET %s: body %q, want %q", tt.path, got, tt.body)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package middlewares
+
+import (
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/manish-npx/simple-go-echo/internal/config"
+	"github.com/manish-npx/simple-go-echo/internal/database"
+	"github.com/manish-npx/simple-go-echo/internal/logger"
+	"github.com/manish-npx/simple-go-echo/internal/utils/response"
+)
+
+// Tenant resolves the request's tenant and points its storage queries at
+// that tenant's database and circuit breaker (see database.WithTenant),
+// keeping the pool open until the request is done. A request without a
+// tenant gets a 400, one naming an unconfigured tenant a 404. The tenant is
+// added to the request logger.
+func Tenant(pools *database.TenantPools, cfg config.Tenancy) echo.MiddlewareFunc {
+	header := cfg.HeaderName()
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			var name string
+			if cfg.Subdomain {
+				name = subdomain(req.Host)
+			} else {
+				name = req.Header.Get(header)
+				// Responses differ per tenant, so shared caches must not
+				// mix them up.
+				c.Response().Header().Add(echo.HeaderVary, header)
+			}
+			if name == "" {
+				return response.BadRequest(c, "Missing tenant")
+			}
+
+			lease, err := pools.Acquire(req.Context(), name)
+			if errors.Is(err, database.ErrUnknownTenant) {
+				return response.NotFound(c, "Unknown tenant")
+			}
+			if err != nil {
+				return response.InternalServerError(c, err)
+			}
+			defer lease.Release()
+
+			ctx := database.WithTenant(req.Context(), name, lease)
+			ctx = logger.NewContext(ctx, logger.FromContext(ctx).With("tenant", name))
+			c.SetRequest(req.WithContext(ctx))
+			return next(c)
+		}
+	}
+}
+
+// subdomain returns the first label of host, e.g. acme for
+// acme.example.com:8080 or acme.localhost, or "" for a bare name.
+func subdomain(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	label, _, ok := strings.Cut(host, ".")
+	if !ok {
+		return ""
+	}
+	return label
+}
@@ -0,0 +1,32 @@
+package middlewares
+
+import (
+	"context"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Timeout puts a deadline on each request's context, so queries running
+// under it are cancelled and the handler answers 504 (see
+// response.InternalServerError). perRoute overrides def for a route,
+// keyed by its registered path such as "/api/todos/:id". A zero or
+// negative timeout means none, for streaming routes.
+func Timeout(def time.Duration, perRoute map[string]time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			timeout, ok := perRoute[c.Path()]
+			if !ok {
+				timeout = def
+			}
+			if timeout <= 0 {
+				return next(c)
+			}
+
+			ctx, cancel := context.WithTimeout(c.Request().Context(), timeout)
+			defer cancel()
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}
+}
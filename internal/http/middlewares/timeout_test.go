@@ -0,0 +1,59 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestTimeoutPerRoute(t *testing.T) {
+	e := echo.New()
+	e.Use(Timeout(5*time.Second, map[string]time.Duration{
+		"/api/todos/stats":  time.Minute,
+		"/api/todos/stream": 0,
+	}))
+	// Each route answers with the time left before its deadline, or
+	// "none".
+	remaining := func(c echo.Context) error {
+		deadline, ok := c.Request().Context().Deadline()
+		if !ok {
+			return c.String(http.StatusOK, "none")
+		}
+		return c.String(http.StatusOK, time.Until(deadline).Round(time.Second).String())
+	}
+	for _, path := range []string{"/api/todos", "/api/todos/:id", "/api/todos/stats", "/api/todos/stream"} {
+		e.GET(path, remaining)
+	}
+
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"/api/todos", "5s"},
+		{"/api/todos/7", "5s"},
+		{"/api/todos/stats", "1m0s"},
+		{"/api/todos/stream", "none"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+		if got := rec.Body.String(); got != tt.want {
+			t.Errorf("GET %s: timeout %s, want %s", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestTimeoutZeroDefault(t *testing.T) {
+	e := echo.New()
+	e.Use(Timeout(0, nil))
+	e.GET("/", func(c echo.Context) error {
+		if _, ok := c.Request().Context().Deadline(); ok {
+			t.Error("request has a deadline, want none")
+		}
+		return c.NoContent(http.StatusNoContent)
+	})
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
@@ -0,0 +1,59 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/manish-npx/simple-go-echo/internal/logger"
+)
+
+// Group runs background goroutines that share one cancellable context, so
+// shutdown can stop them all and wait for them to return.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	log    logger.Logger
+}
+
+func NewGroup(log logger.Logger) *Group {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Group{ctx: ctx, cancel: cancel, log: log}
+}
+
+// Go starts fn in a goroutine. fn must return promptly once ctx is done;
+// anything it still has in flight should be finished or rolled back first.
+func (g *Group) Go(name string, fn func(ctx context.Context)) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				g.log.Error("job panicked", "job", name, "panic", r)
+			}
+		}()
+
+		fn(g.ctx)
+		g.log.Info("job stopped", "job", name)
+	}()
+}
+
+// Shutdown cancels every job and waits up to timeout for them to return.
+func (g *Group) Shutdown(timeout time.Duration) error {
+	g.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		return errors.New("timed out waiting for background jobs")
+	}
+}
@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Logger is the structured logger used across the app. Arguments after the
+// message are key/value pairs, e.g. log.Info("todo created", "id", id).
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+	With(args ...any) Logger
+}
+
+type slogLogger struct {
+	l *slog.Logger
+	// level is shared by every logger derived via With, so SetLevel
+	// affects them all. It is nil for Nop.
+	level *slog.LevelVar
+}
+
+func (s slogLogger) Debug(msg string, args ...any) { s.l.Debug(msg, args...) }
+func (s slogLogger) Info(msg string, args ...any)  { s.l.Info(msg, args...) }
+func (s slogLogger) Warn(msg string, args ...any)  { s.l.Warn(msg, args...) }
+func (s slogLogger) Error(msg string, args ...any) { s.l.Error(msg, args...) }
+
+func (s slogLogger) With(args ...any) Logger {
+	return slogLogger{l: s.l.With(args...), level: s.level}
+}
+
+// New builds a logger writing to stdout. format is "json" or "text";
+// level is one of debug, info, warn, error (default info).
+func New(level, format string) Logger {
+	return newLogger(os.Stdout, level, format)
+}
+
+func newLogger(w io.Writer, level, format string) Logger {
+	lv := new(slog.LevelVar)
+	lv.Set(ParseLevel(level))
+	opts := &slog.HandlerOptions{Level: lv}
+
+	var h slog.Handler
+	if strings.EqualFold(format, "json") {
+		h = slog.NewJSONHandler(w, opts)
+	} else {
+		h = slog.NewTextHandler(w, opts)
+	}
+	return slogLogger{l: slog.New(h), level: lv}
+}
+
+// SetLevel changes the minimum level of l and every logger derived from it
+// at runtime. It reports false if l was not built by New.
+func SetLevel(l Logger, level string) bool {
+	s, ok := l.(slogLogger)
+	if !ok || s.level == nil {
+		return false
+	}
+	s.level.Set(ParseLevel(level))
+	return true
+}
+
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// Nop returns a logger that discards everything; handy in tests.
+func Nop() Logger {
+	return slogLogger{l: slog.New(slog.DiscardHandler)}
+}
+
+type ctxKey struct{}
+
+// NewContext returns a copy of ctx carrying l.
+func NewContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext returns the logger stored in ctx, or a no-op logger.
+func FromContext(ctx context.Context) Logger {
+	if l, ok := ctx.Value(ctxKey{}).(Logger); ok {
+		return l
+	}
+	return Nop()
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+// Bool is a bool that also accepts the strings "true"/"false" and the
+// numbers 1/0 in JSON, for clients that send form-style values. It always
+// encodes as a plain JSON boolean.
+type Bool bool
+
+func (b *Bool) UnmarshalJSON(data []byte) error {
+	switch string(bytes.TrimSpace(data)) {
+	case "true", `"true"`, "1":
+		*b = true
+	case "false", `"false"`, "0":
+		*b = false
+	case "null":
+		// Like a plain bool, null leaves the value unchanged.
+	default:
+		// An UnmarshalTypeError lets the decoder add the field name, so
+		// clients get e.g. `Field "done" must be a boolean`.
+		return &json.UnmarshalTypeError{Value: describeJSON(data), Type: reflect.TypeFor[bool]()}
+	}
+	return nil
+}
+
+// describeJSON names the kind of a raw JSON value for error messages.
+func describeJSON(data []byte) string {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return "nothing"
+	}
+	switch data[0] {
+	case '"':
+		return "string " + string(data)
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	case 't', 'f':
+		return "bool"
+	default:
+		return "number " + string(data)
+	}
+}
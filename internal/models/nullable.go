@@ -0,0 +1,25 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// NullableString is an optional string in a patch body, where leaving a
+// field out and sending null mean different things: absent leaves the
+// value alone (Set is false), null clears it (Set is true, Value nil).
+type NullableString struct {
+	Set   bool
+	Value *string
+}
+
+// UnmarshalJSON is only called for fields present in the body, null
+// included, which is what makes Set meaningful.
+func (n *NullableString) UnmarshalJSON(data []byte) error {
+	n.Set = true
+	if string(bytes.TrimSpace(data)) == "null" {
+		n.Value = nil
+		return nil
+	}
+	return json.Unmarshal(data, &n.Value)
+}
@@ -1,7 +1,101 @@
 package models
 
+import (
+	"encoding/xml"
+	"strconv"
+	"time"
+)
+
+// Todo maps to a row of the todos table; db tags name the columns for
+// pgx.RowToStructByName. The title_length and description_length rules
+// are configurable limits (see validation.Limits).
 type Todo struct {
-	ID    int64  `json:"id"`
-	Title string `json:"title" validate:"required"`
-	Done  bool   `json:"done"`
+	XMLName xml.Name `json:"-" xml:"todo" db:"-"`
+	ID      int64    `json:"id" xml:"id" db:"id"`
+	// PublicID is generated by the database. With api.id_type: uuid it is
+	// the id in URLs instead of ID.
+	PublicID string `json:"public_id" xml:"public_id" db:"public_id"`
+	Title    string `json:"title" xml:"title" db:"title" validate:"required,notblank,title_length"`
+	// Description is optional; NULL in the database and null in JSON.
+	Description *string `json:"description" xml:"description,omitempty" db:"description" validate:"omitempty,description_length"`
+	Done        Bool    `json:"done" xml:"done" db:"done"`
+	// ExternalID identifies a todo synced from another system. It can be
+	// set on create or upsert; updates leave it unchanged.
+	ExternalID *string   `json:"external_id" xml:"external_id,omitempty" db:"external_id" validate:"omitempty,notblank,max=255"`
+	CreatedAt  time.Time `json:"created_at" xml:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" xml:"updated_at" db:"updated_at"`
+}
+
+// TodoStats is one bucket of the todo activity time series.
+type TodoStats struct {
+	Bucket    time.Time `json:"bucket" xml:"bucket"`
+	Created   int       `json:"created" xml:"created"`
+	Completed int       `json:"completed" xml:"completed"`
+}
+
+// Operations accepted by POST /todos/batch-ops.
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// TodoOp is one operation of a mixed batch. ID is required for update and
+// delete; Todo for create and update.
+type TodoOp struct {
+	Op   string `json:"op"`
+	ID   int64  `json:"id"`
+	Todo *Todo  `json:"todo"`
+}
+
+// TodoOpResult reports an applied TodoOp. Todo is the row as written; it
+// is nil for deletes.
+type TodoOpResult struct {
+	XMLName xml.Name `json:"-" xml:"result"`
+	Op      string   `json:"op" xml:"op"`
+	ID      int64    `json:"id" xml:"id"`
+	Todo    *Todo    `json:"todo,omitempty" xml:"todo,omitempty"`
+}
+
+// TodoPatch is a partial update: nil fields are left unchanged. Description
+// can also be cleared by sending null.
+type TodoPatch struct {
+	Title       *string        `json:"title" validate:"omitempty,notblank,title_length"`
+	Description NullableString `json:"description" validate:"omitempty,description_length"`
+	Done        *Bool          `json:"done"`
+}
+
+// Apply copies the set fields onto t and returns the JSON names of those
+// whose value actually changed.
+func (p TodoPatch) Apply(t *Todo) []string {
+	changed := []string{}
+	if p.Title != nil && *p.Title != t.Title {
+		t.Title = *p.Title
+		changed = append(changed, "title")
+	}
+	if p.Description.Set && !equalPtr(p.Description.Value, t.Description) {
+		t.Description = p.Description.Value
+		changed = append(changed, "description")
+	}
+	if p.Done != nil && *p.Done != t.Done {
+		t.Done = *p.Done
+		changed = append(changed, "done")
+	}
+	return changed
+}
+
+// equalPtr reports whether a and b are both nil or point to equal values.
+func equalPtr[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func (t Todo) ResourceType() string {
+	return "todos"
+}
+
+func (t Todo) ResourceID() string {
+	return strconv.FormatInt(t.ID, 10)
 }
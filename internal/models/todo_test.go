@@ -0,0 +1,59 @@
+package models
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func decodePatch(t *testing.T, body string) TodoPatch {
+	t.Helper()
+	var p TodoPatch
+	if err := json.Unmarshal([]byte(body), &p); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestTodoPatchApply(t *testing.T) {
+	desc := "milk, eggs"
+	tests := []struct {
+		name     string
+		body     string
+		wantDesc *string
+		changed  []string
+	}{
+		{"no-op", `{"title": "Shopping", "description": "milk, eggs", "done": false}`, &desc, []string{}},
+		{"absent leaves description", `{"done": true}`, &desc, []string{"done"}},
+		{"null clears description", `{"description": null}`, nil, []string{"description"}},
+		{"new description", `{"description": "bread"}`, ptr("bread"), []string{"description"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todo := Todo{Title: "Shopping", Description: &desc}
+			changed := decodePatch(t, tt.body).Apply(&todo)
+			if !slices.Equal(changed, tt.changed) {
+				t.Errorf("changed = %q, want %q", changed, tt.changed)
+			}
+			if !equalPtr(todo.Description, tt.wantDesc) {
+				t.Errorf("description = %v, want %v", todo.Description, tt.wantDesc)
+			}
+		})
+	}
+}
+
+func TestTodoPatchNullOnClearedDescriptionIsNoOp(t *testing.T) {
+	todo := Todo{Title: "Shopping"}
+	if changed := decodePatch(t, `{"description": null}`).Apply(&todo); len(changed) != 0 {
+		t.Errorf("changed = %q, want none", changed)
+	}
+}
+
+func TestNullableStringRejectsNonStrings(t *testing.T) {
+	var p TodoPatch
+	if err := json.Unmarshal([]byte(`{"description": 5}`), &p); err == nil {
+		t.Error("err = nil, want a type error")
+	}
+}
+
+func ptr(s string) *string { return &s }
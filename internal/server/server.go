@@ -1,31 +1,135 @@
 package server
 
 import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"iter"
+	"maps"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/manish-npx/simple-go-echo/internal/config"
+	"github.com/manish-npx/simple-go-echo/internal/database"
+	"github.com/manish-npx/simple-go-echo/internal/http/binder"
 	"github.com/manish-npx/simple-go-echo/internal/http/handlers"
+	"github.com/manish-npx/simple-go-echo/internal/http/middlewares"
+	"github.com/manish-npx/simple-go-echo/internal/jobs"
+	"github.com/manish-npx/simple-go-echo/internal/logger"
+	"github.com/manish-npx/simple-go-echo/internal/models"
 	"github.com/manish-npx/simple-go-echo/internal/storage"
 	"github.com/manish-npx/simple-go-echo/internal/utils/response"
+	"github.com/manish-npx/simple-go-echo/internal/validation"
+)
+
+const (
+	defaultShutdownTimeout   = 10 * time.Second
+	defaultForceCloseTimeout = 5 * time.Second
+	defaultCORSMaxAge        = 600
 )
 
+// ErrHandlersStuck is returned by Shutdown when handlers were still running
+// after the forced close. Their database connections are still checked out,
+// so closing the pool would block.
+var ErrHandlersStuck = errors.New("handlers still running after forced close")
+
 type Server struct {
-	echo *echo.Echo
-	cfg  *config.Config
+	echo   *echo.Echo
+	cfg    *config.Config
+	health *handlers.HealthHandler
+	todos  *storage.TodoStorage
+	log    logger.Logger
+
+	// inflight counts running handlers so a forced shutdown can wait for
+	// them; Echo stops tracking requests once it closes connections.
+	inflight *atomic.Int64
 }
 
-func NewServer(cfg *config.Config, db *pgxpool.Pool) *Server {
+// NewServer builds the Echo instance and registers every route. It fails if
+// a method and path are registered twice. tenants is nil unless tenancy is
+// enabled; /api queries then go to each tenant's database instead of db,
+// guarded by the tenant's own breaker rather than breaker.
+// queryStats is nil unless database.top_slow_queries is set.
+func NewServer(cfg *config.Config, db *pgxpool.Pool, tenants *database.TenantPools, breaker *database.Breaker, queryStats *database.QueryStats, log logger.Logger, build handlers.BuildInfo) (*Server, error) {
 	e := echo.New()
+	routes := &routeGuard{seen: map[string]bool{}}
+	e.OnAddRouteHandler = routes.onAdd
+	e.HideBanner = cfg.HideBanner()
+	e.HidePort = cfg.HidePort()
+
+	// Pre-routing: runs before the router, so it can change the path.
+	switch cfg.Server.TrailingSlash {
+	case "", "strip":
+		e.Pre(middleware.RemoveTrailingSlash())
+	case "redirect":
+		// 301 for reads; 308 elsewhere, since clients may turn a
+		// redirected POST into a GET after a 301.
+		e.Pre(middleware.RemoveTrailingSlashWithConfig(middleware.TrailingSlashConfig{
+			RedirectCode: http.StatusMovedPermanently,
+			Skipper: func(c echo.Context) bool {
+				m := c.Request().Method
+				return m != http.MethodGet && m != http.MethodHead
+			},
+		}))
+		e.Pre(middleware.RemoveTrailingSlashWithConfig(middleware.TrailingSlashConfig{
+			RedirectCode: http.StatusPermanentRedirect,
+		}))
+	}
 
 	// Middleware
-	e.Use(middleware.Logger())
-	e.Use(middleware.Recover())
+	inflight := new(atomic.Int64)
+	e.Use(trackInflight(inflight))
+	e.Use(middlewares.CorrelationID())
+	e.Use(middleware.RequestID())
+	e.Use(middlewares.ContextLogger(log))
+	// Recover wraps every middleware below, so a panic in the access log,
+	// Decompress or BodyLog is recovered too. Such requests skip the
+	// access log, so the panic is logged here with the request ID.
+	e.Use(middleware.RecoverWithConfig(middleware.RecoverConfig{
+		LogErrorFunc: func(c echo.Context, err error, stack []byte) error {
+			logger.FromContext(c.Request().Context()).Error("panic recovered",
+				"error", err,
+				"method", c.Request().Method,
+				"uri", c.Request().RequestURI,
+				"stack", string(stack),
+			)
+			return err
+		},
+	}))
+	e.Use(middlewares.Features(cfg.Features))
+	e.Use(middlewares.AccessLog(cfg.Server.AccessLogSkip))
+	if n := cfg.Server.DecompressMaxBytes; n > 0 {
+		e.Use(middlewares.Decompress(n))
+	}
+	if cfg.Server.DebugBodies {
+		if cfg.IsProduction() {
+			log.Error("server.debug_bodies is ignored in production")
+		} else {
+			e.Use(middlewares.BodyLog(cfg.Server.DebugBodyMaxBytes))
+		}
+	}
+	e.Use(middlewares.Timeout(cfg.Server.RequestTimeout, routeTimeouts(cfg.Server.RouteTimeouts)))
+	e.Use(middlewares.CacheControl(cfg.Server.CacheControl))
 
+	maxAge := cfg.CORS.MaxAgeSeconds
+	if maxAge == 0 {
+		maxAge = defaultCORSMaxAge
+	}
+	allowHeaders := []string{"Content-Type", "Authorization"}
+	if cfg.Tenancy.Enabled && !cfg.Tenancy.Subdomain {
+		allowHeaders = append(allowHeaders, cfg.Tenancy.HeaderName())
+	}
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"http://localhost:3000", "http://127.0.0.1:3000", "http://localhost:5173"},
-		AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders: []string{"Content-Type", "Authorization"},
+		AllowOrigins: cfg.CORS.AllowOrigins,
+		AllowMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders: allowHeaders,
+		MaxAge:       maxAge,
 	}))
 
 	// e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
@@ -36,25 +140,254 @@ func NewServer(cfg *config.Config, db *pgxpool.Pool) *Server {
 	// e.Use(middleware.CORS())
 
 	e.HTTPErrorHandler = response.CustomErrorHandler
+	e.Binder = binder.New(binder.Options{
+		Strict:    cfg.Server.StrictJSON,
+		MaxDepth:  cfg.Server.JSONMaxDepth,
+		MaxTokens: cfg.Server.JSONMaxTokens,
+	})
+	validator := validation.New(validation.Limits{
+		TitleMaxLength:       cfg.API.TitleMaxLength,
+		DescriptionMaxLength: cfg.API.DescriptionMaxLength,
+	})
+	e.Validator = validator
+	switch cfg.API.JSONNaming {
+	case "", "snake_case":
+	case "camelCase":
+		e.JSONSerializer = response.CamelCaseSerializer{}
+	default:
+		log.Error("unknown api.json_naming, using snake_case", "value", cfg.API.JSONNaming)
+	}
 
 	// Initialize storage and handlers
-	todoStorage := storage.NewTodoStorage(db)
-	todoHandler := handlers.NewTodoHandler(todoStorage)
+	todoStorage := storage.NewTodoStorage(db, log, storage.Options{
+		Retry: storage.RetryPolicy{
+			MaxRetries: cfg.Database.MaxRetries,
+			Backoff:    cfg.Database.RetryBackoff,
+		},
+		ListCacheTTL:  cfg.API.ListCacheTTL,
+		NoReturning:   !cfg.Database.Returning(),
+		CoalesceReads: cfg.Database.CoalesceReads,
+	})
+	todoHandler := handlers.NewTodoHandler(todoStorage, cfg.API)
+
+	// Probes
+	healthHandler := handlers.NewHealthHandler(db, cfg.Database.ReadyCheckSchema)
+	e.GET("/health", healthHandler.Health)
+	e.GET("/ready", healthHandler.Ready)
+	e.GET("/version", handlers.Version(build))
+	e.GET("/metrics", echo.WrapHandler(expvar.Handler()))
+
+	if cfg.Server.DebugConfig || queryStats != nil {
+		if cfg.IsProduction() && len(cfg.Auth.APIKeys) == 0 {
+			log.Error("debug endpoints (server.debug_config, database.top_slow_queries) are ignored in production without auth.api_keys")
+		} else {
+			debug := e.Group("/debug")
+			if len(cfg.Auth.APIKeys) > 0 {
+				debug.Use(middlewares.APIKeyAuth(cfg.Auth.APIKeys))
+			}
+			if cfg.Server.DebugConfig {
+				debug.GET("/config", handlers.DebugConfig(cfg))
+			}
+			if queryStats != nil {
+				debug.GET("/slow-queries", handlers.SlowQueries(queryStats))
+			}
+		}
+	}
 
 	// Routes
 	api := e.Group("/api")
-	api.GET("/todos", todoHandler.GetAll)
-	api.POST("/todos/create", todoHandler.Create)
-	api.GET("/todos/:id", todoHandler.GetByID)
-	api.PUT("/todos/update/:id", todoHandler.Update)
-	api.DELETE("/todos/:id", todoHandler.Delete)
+	if len(cfg.Auth.APIKeys) > 0 {
+		api.Use(middlewares.APIKeyAuth(cfg.Auth.APIKeys))
+	}
+	if len(cfg.API.RequiredHeaders) > 0 {
+		api.Use(middlewares.RequireHeaders(cfg.API.RequiredHeaders))
+	}
+	if n := cfg.Server.MaxConcurrentRequests; n > 0 {
+		api.Use(middlewares.ConcurrencyLimit(n))
+	}
+	if tenants != nil {
+		api.Use(middlewares.Tenant(tenants, cfg.Tenancy))
+	}
+	if n := cfg.Server.ShedWaitThreshold; n > 0 {
+		api.Use(middlewares.LoadShed(db, n))
+	}
+	api.Use(middlewares.CircuitBreaker(breaker))
+	if cfg.API.IDType == "uuid" {
+		api.Use(middlewares.PublicID(todoStorage.IDByPublicID))
+	}
+	handlers.RegisterCrud(api, handlers.CrudRoutes{
+		GetAll:  "/todos",
+		GetByID: "/todos/:id",
+		Create:  "/todos/create",
+		Update:  "/todos/update/:id",
+		Delete:  "/todos/:id",
+	}, todoHandler)
+	api.GET("/todos/stream", todoHandler.Stream)
+	api.GET("/todos/recent", todoHandler.GetRecent)
+	api.GET("/todos/grouped", todoHandler.GetGrouped)
+	api.GET("/todos/count", todoHandler.Count)
+	api.GET("/todos/stats", todoHandler.Stats)
+	api.POST("/todos/upsert", todoHandler.Upsert)
+	api.POST("/todos/batch-ops", todoHandler.BatchOps)
+	api.GET("/todos/:id/adjacent", todoHandler.GetAdjacent)
+	api.GET("/todos/:id/detail", todoHandler.GetDetail)
+	api.PATCH("/todos/:id", todoHandler.Patch)
+	api.POST("/todos/:id/toggle", todoHandler.Toggle)
+	api.POST("/todos/:id/duplicate", todoHandler.Duplicate)
+	api.POST("/todos/all/done", todoHandler.SetAllDone)
+	api.GET("/schema/todo", handlers.Schema(validator, models.Todo{}))
+
+	if err := routes.err(); err != nil {
+		return nil, err
+	}
+	checkRouteKeys(e, "server.route_timeouts", maps.Keys(cfg.Server.RouteTimeouts), log)
+	checkRouteKeys(e, "server.cache_control", maps.Keys(cfg.Server.CacheControl), log)
 
 	return &Server{
-		echo: e,
-		cfg:  cfg,
+		echo:     e,
+		cfg:      cfg,
+		health:   healthHandler,
+		todos:    todoStorage,
+		log:      log,
+		inflight: inflight,
+	}, nil
+}
+
+// streamingRoutes send their response over as long as the data takes, so
+// they get no request timeout unless server.route_timeouts sets one.
+var streamingRoutes = []string{"/api/todos/stream"}
+
+// routeTimeouts returns the configured per-route timeouts plus a zero, i.e.
+// no timeout, for each streaming route they leave out.
+func routeTimeouts(configured map[string]time.Duration) map[string]time.Duration {
+	timeouts := maps.Clone(configured)
+	if timeouts == nil {
+		timeouts = map[string]time.Duration{}
+	}
+	for _, path := range streamingRoutes {
+		if _, ok := timeouts[path]; !ok {
+			timeouts[path] = 0
+		}
+	}
+	return timeouts
+}
+
+// routeGuard sees every route as it is registered. Echo quietly lets a
+// second registration of the same method and path replace the first, so a
+// copy-pasted route would shadow another one without any error.
+type routeGuard struct {
+	seen map[string]bool
+	dups []string
+}
+
+func (g *routeGuard) onAdd(host string, r echo.Route, _ echo.HandlerFunc, _ []echo.MiddlewareFunc) {
+	if r.Method == echo.RouteNotFound {
+		// Group.Use registers these catch-alls on every call.
+		return
+	}
+	key := r.Method + " " + host + r.Path
+	if g.seen[key] {
+		g.dups = append(g.dups, key)
+	}
+	g.seen[key] = true
+}
+
+func (g *routeGuard) err() error {
+	if len(g.dups) == 0 {
+		return nil
+	}
+	return fmt.Errorf("route registered more than once: %s", strings.Join(g.dups, ", "))
+}
+
+// checkRouteKeys reports the keys of a per-route setting that match no
+// route, which would otherwise be silently ignored.
+func checkRouteKeys(e *echo.Echo, setting string, keys iter.Seq[string], log logger.Logger) {
+	paths := map[string]bool{}
+	for _, r := range e.Routes() {
+		paths[r.Path] = true
+	}
+	for path := range keys {
+		if !paths[path] {
+			log.Error(setting+" entry matches no route", "path", path)
+		}
+	}
+}
+
+func trackInflight(n *atomic.Int64) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			n.Add(1)
+			defer n.Add(-1)
+			return next(c)
+		}
+	}
+}
+
+// StartJobs launches the server's background work in g.
+func (s *Server) StartJobs(g *jobs.Group) {
+	if s.cfg.API.ListCacheTTL > 0 && s.cfg.API.ListCacheNotify {
+		g.Go("todo-change-listener", s.todos.ListenForChanges)
 	}
 }
 
 func (s *Server) Start() error {
-	return s.echo.Start(s.cfg.Server.Addr)
+	err := s.echo.Start(s.cfg.Server.Addr)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown fails readiness first, waits PreShutdownDelay so the load
+// balancer can stop routing to us, then drains in-flight requests. If they
+// outlast ShutdownTimeout it escalates: every connection is closed, which
+// cancels the requests' contexts, and handlers get ForceCloseTimeout to
+// return before ErrHandlersStuck is reported.
+func (s *Server) Shutdown() error {
+	s.health.StartDraining()
+
+	if delay := s.cfg.Server.PreShutdownDelay; delay > 0 {
+		s.log.Info("readiness failing, waiting before shutdown", "delay", delay)
+		time.Sleep(delay)
+	}
+
+	timeout := s.cfg.Server.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := s.echo.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	s.log.Warn("graceful shutdown timed out, escalating to forced close",
+		"timeout", timeout, "inflight", s.inflight.Load())
+	if err := s.echo.Close(); err != nil {
+		return fmt.Errorf("forced close: %w", err)
+	}
+
+	forceTimeout := s.cfg.Server.ForceCloseTimeout
+	if forceTimeout <= 0 {
+		forceTimeout = defaultForceCloseTimeout
+	}
+	if !s.waitIdle(forceTimeout) {
+		return fmt.Errorf("%w: %d after %s", ErrHandlersStuck, s.inflight.Load(), forceTimeout)
+	}
+	s.log.Warn("server closed forcibly")
+	return nil
+}
+
+// waitIdle polls until no handler is running or timeout passes.
+func (s *Server) waitIdle(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for s.inflight.Load() > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return true
 }
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/labstack/echo/v4"
+	"github.com/manish-npx/simple-go-echo/internal/config"
+	"github.com/manish-npx/simple-go-echo/internal/database"
+	"github.com/manish-npx/simple-go-echo/internal/http/handlers"
+	"github.com/manish-npx/simple-go-echo/internal/logger"
+)
+
+// newTestServer builds the server for cfg on a pool that never connects,
+// so only routes that don't query the database can be exercised.
+func newTestServer(t *testing.T, cfg *config.Config) *Server {
+	t.Helper()
+	db, err := pgxpool.New(context.Background(), "postgres://test@127.0.0.1:1/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+
+	s, err := NewServer(cfg, db, nil, database.NewBreaker(0, 0), nil, logger.Nop(), handlers.BuildInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func (s *Server) serve(req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	s.echo.ServeHTTP(rec, req)
+	return rec
+}
+
+func preflight() *http.Request {
+	req := httptest.NewRequest(http.MethodOptions, "/api/todos", nil)
+	req.Header.Set("Origin", "http://localhost:3000")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	return req
+}
+
+func TestCORSPreflightMaxAge(t *testing.T) {
+	tests := []struct {
+		name   string
+		maxAge int
+		want   string
+	}{
+		{"configured", 120, "120"},
+		{"default", 0, "600"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.CORS.AllowOrigins = []string{"http://localhost:3000"}
+			cfg.CORS.MaxAgeSeconds = tt.maxAge
+
+			rec := newTestServer(t, cfg).serve(preflight())
+			if rec.Code != http.StatusNoContent {
+				t.Fatalf("status = %d, want 204", rec.Code)
+			}
+			if got := rec.Header().Get("Access-Control-Max-Age"); got != tt.want {
+				t.Errorf("Access-Control-Max-Age = %q, want %q", got, tt.want)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "http://localhost:3000" {
+				t.Errorf("Access-Control-Allow-Origin = %q", got)
+			}
+		})
+	}
+}
+
+func TestUnmatchedRouteNotFound(t *testing.T) {
+	s := newTestServer(t, &config.Config{})
+	for _, path := range []string{"/api/todoz", "/nope", "/api/todos/1/nope"} {
+		rec := s.serve(httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s: status %d, want 404", path, rec.Code)
+		}
+		if ct := rec.Header().Get(echo.HeaderContentType); !strings.HasPrefix(ct, echo.MIMEApplicationJSON) {
+			t.Errorf("GET %s: Content-Type %q, want JSON", path, ct)
+		}
+		var body map[string]string
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("GET %s: body %s: %v", path, rec.Body, err)
+		}
+		if want := map[string]string{"error": "route not found", "code": "NOT_FOUND"}; !maps.Equal(body, want) {
+			t.Errorf("GET %s: body %v, want %v", path, body, want)
+		}
+	}
+}
+
+func TestRouteTimeoutsExemptStreaming(t *testing.T) {
+	got := routeTimeouts(nil)
+	if d, ok := got["/api/todos/stream"]; !ok || d != 0 {
+		t.Errorf("stream timeout = %v (set %v), want 0", d, ok)
+	}
+
+	configured := map[string]time.Duration{"/api/todos/stream": time.Minute, "/api/todos/stats": 30 * time.Second}
+	got = routeTimeouts(configured)
+	if got["/api/todos/stream"] != time.Minute || got["/api/todos/stats"] != 30*time.Second {
+		t.Errorf("timeouts = %v, want the configured ones", got)
+	}
+	if len(configured) != 2 {
+		t.Errorf("configured map was modified: %v", configured)
+	}
+}
+
+func TestTrailingSlash(t *testing.T) {
+	tests := []struct {
+		mode   string
+		method string
+		code   int
+		loc    string
+	}{
+		{"", http.MethodGet, http.StatusOK, ""},
+		{"strip", http.MethodGet, http.StatusOK, ""},
+		{"redirect", http.MethodGet, http.StatusMovedPermanently, "/health"},
+		{"redirect", http.MethodPost, http.StatusPermanentRedirect, "/health"},
+		{"off", http.MethodGet, http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode+" "+tt.method, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Server.TrailingSlash = tt.mode
+			s := newTestServer(t, cfg)
+
+			plain := s.serve(httptest.NewRequest(http.MethodGet, "/health", nil))
+			if plain.Code != http.StatusOK {
+				t.Fatalf("GET /health: status %d, want 200", plain.Code)
+			}
+
+			rec := s.serve(httptest.NewRequest(tt.method, "/health/", nil))
+			if rec.Code != tt.code {
+				t.Errorf("%s /health/: status %d, want %d", tt.method, rec.Code, tt.code)
+			}
+			if got := rec.Header().Get(echo.HeaderLocation); got != tt.loc {
+				t.Errorf("Location = %q, want %q", got, tt.loc)
+			}
+			if tt.code == http.StatusOK && rec.Body.String() != plain.Body.String() {
+				t.Errorf("/health/ body %s, want the /health body %s", rec.Body, plain.Body)
+			}
+		})
+	}
+}
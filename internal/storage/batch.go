@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/manish-npx/simple-go-echo/internal/models"
+)
+
+// OpError reports which operation of a batch failed. Err keeps its kind
+// (e.g. ErrTodoNotFound), so it can be mapped to a status.
+type OpError struct {
+	Index int
+	Err   error
+}
+
+func (e *OpError) Error() string { return fmt.Sprintf("operation %d: %v", e.Index, e.Err) }
+func (e *OpError) Unwrap() error { return e.Err }
+
+// ApplyOps runs ops in order in one transaction. If any of them fails,
+// none is applied and the error is an *OpError. Ops must already be
+// validated: known Op, positive ID for update and delete, Todo set for
+// create and update.
+func (s *TodoStorage) ApplyOps(ctx context.Context, ops []models.TodoOp) ([]models.TodoOpResult, error) {
+	defer s.cache.invalidate()
+
+	var results []models.TodoOpResult
+	err := pgx.BeginFunc(ctx, s.db(ctx), func(tx pgx.Tx) error {
+		results = make([]models.TodoOpResult, 0, len(ops))
+		for i, op := range ops {
+			result, err := applyOp(ctx, tx, op)
+			if err != nil {
+				return &OpError{Index: i, Err: err}
+			}
+			results = append(results, result)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// applyOp writes without RETURNING and re-selects the row, which works
+// whether or not the database path supports RETURNING; the transaction
+// already pins one connection.
+func applyOp(ctx context.Context, tx pgx.Tx, op models.TodoOp) (models.TodoOpResult, error) {
+	result := models.TodoOpResult{Op: op.Op, ID: op.ID}
+
+	switch op.Op {
+	case models.OpCreate:
+		t := op.Todo
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO todos (title, description, done, external_id) VALUES ($1, $2, $3, $4)`,
+			t.Title, t.Description, t.Done, t.ExternalID,
+		); err != nil {
+			return result, writeError(err)
+		}
+		todo, err := collectTodo(tx.Query(ctx, `SELECT `+todoColumns+` FROM todos WHERE id = lastval()`))
+		if err != nil {
+			return result, err
+		}
+		result.ID, result.Todo = todo.ID, &todo
+
+	case models.OpUpdate:
+		t := op.Todo
+		tag, err := tx.Exec(ctx,
+			`UPDATE todos SET title=$1, description=$2, done=$3, updated_at=CURRENT_TIMESTAMP WHERE id=$4`,
+			t.Title, t.Description, t.Done, op.ID,
+		)
+		if err != nil {
+			return result, todoError("update", op.ID, err)
+		}
+		if tag.RowsAffected() == 0 {
+			return result, ErrTodoNotFound
+		}
+		todo, err := collectTodo(tx.Query(ctx, `SELECT `+todoColumns+` FROM todos WHERE id=$1`, op.ID))
+		if err != nil {
+			return result, todoError("update", op.ID, err)
+		}
+		result.Todo = &todo
+
+	case models.OpDelete:
+		tag, err := tx.Exec(ctx, `DELETE FROM todos WHERE id=$1`, op.ID)
+		if err != nil {
+			return result, todoError("delete", op.ID, err)
+		}
+		if tag.RowsAffected() == 0 {
+			return result, ErrTodoNotFound
+		}
+
+	default:
+		return result, fmt.Errorf("unknown operation %q", op.Op)
+	}
+	return result, nil
+}
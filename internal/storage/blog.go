@@ -8,7 +8,11 @@ type BlogStorage struct {
 	DB *pgxpool.Pool
 }
 
+// NewBlogStorage panics if db is nil, like NewTodoStorage.
 func NewBlogStorage(db *pgxpool.Pool) *BlogStorage {
+	if db == nil {
+		panic("storage: NewBlogStorage called with a nil pool")
+	}
 	return &BlogStorage{DB: db}
 }
 
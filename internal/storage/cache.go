@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"expvar"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/manish-npx/simple-go-echo/internal/models"
+	"github.com/manish-npx/simple-go-echo/internal/utils/queryparams"
+)
+
+// maxListCacheEntries bounds memory when clients page through many
+// distinct offsets; the cache is simply emptied when it fills up.
+const maxListCacheEntries = 1000
+
+var (
+	listCacheHits   = expvar.NewInt("todo_list_cache_hits")
+	listCacheMisses = expvar.NewInt("todo_list_cache_misses")
+)
+
+type listCacheEntry struct {
+	todos   []models.Todo
+	total   int
+	expires time.Time
+}
+
+// listCache memoises GetAll pages for ttl. Every write invalidates it. A
+// nil *listCache is valid and caches nothing.
+type listCache struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	entries map[string]listCacheEntry
+	// gen is bumped on invalidate so a query that started before a write
+	// does not store its (now stale) result.
+	gen uint64
+}
+
+func newListCache(ttl time.Duration) *listCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &listCache{ttl: ttl, entries: map[string]listCacheEntry{}}
+}
+
+// listCacheKey includes the tenant, so tenants never see each other's
+// pages.
+func listCacheKey(tenant string, q queryparams.ListQuery) string {
+	// fmt prints maps with sorted keys, so equal queries give equal keys.
+	return fmt.Sprintf("%s|%+v", tenant, q)
+}
+
+// get returns a cached page and the generation to pass to set on a miss.
+func (c *listCache) get(key string) ([]models.Todo, int, uint64, bool) {
+	if c == nil {
+		return nil, 0, 0, false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	e, ok := c.entries[key]
+	if !ok || time.Now().After(e.expires) {
+		listCacheMisses.Add(1)
+		return nil, 0, c.gen, false
+	}
+	listCacheHits.Add(1)
+	return e.todos, e.total, c.gen, true
+}
+
+func (c *listCache) set(key string, gen uint64, todos []models.Todo, total int) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != c.gen {
+		return
+	}
+	if len(c.entries) >= maxListCacheEntries {
+		clear(c.entries)
+	}
+	c.entries[key] = listCacheEntry{todos: todos, total: total, expires: time.Now().Add(c.ttl)}
+}
+
+func (c *listCache) invalidate() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	clear(c.entries)
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/manish-npx/simple-go-echo/internal/logger"
+	"github.com/manish-npx/simple-go-echo/internal/models"
+)
+
+// testStorage returns a TodoStorage on a fresh schema of the database at
+// TEST_DATABASE_URL with every migration applied, and drops the schema when
+// the test ends. Tests that call it are skipped when the variable is unset.
+func testStorage(t *testing.T, opts Options) *TodoStorage {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	admin, err := pgx.Connect(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Close(ctx) })
+	if _, err := admin.Exec(ctx, `CREATE SCHEMA `+schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec(ctx, `DROP SCHEMA `+schema+` CASCADE`); err != nil {
+			t.Error(err)
+		}
+	})
+
+	cfg, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+
+	files, err := filepath.Glob("../../migrations/*.sql")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no migrations found: %v", err)
+	}
+	for _, f := range files {
+		sql, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Without arguments Exec uses the simple protocol, which runs every
+		// statement in the file.
+		if _, err := pool.Exec(ctx, string(sql)); err != nil {
+			t.Fatalf("%s: %v", f, err)
+		}
+	}
+	return NewTodoStorage(pool, logger.Nop(), opts)
+}
+
+// createTodos inserts a todo per title, in order, and returns them.
+func createTodos(t *testing.T, s *TodoStorage, titles ...string) []models.Todo {
+	t.Helper()
+	todos := make([]models.Todo, len(titles))
+	for i, title := range titles {
+		todos[i].Title = title
+		if _, err := s.Create(context.Background(), &todos[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return todos
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// todosChangedChannel is notified by the todos_changed trigger
+// (migrations/0004_notify_todos_changed.sql).
+const todosChangedChannel = "todos_changed"
+
+const (
+	listenRetryMin = time.Second
+	listenRetryMax = 30 * time.Second
+)
+
+// ListenForChanges clears the list cache whenever any instance, or anyone
+// else, writes to todos. It holds one dedicated connection and reconnects
+// with backoff until ctx is done. Run it only when the cache is enabled.
+func (s *TodoStorage) ListenForChanges(ctx context.Context) {
+	backoff := listenRetryMin
+	for {
+		err := s.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		s.log.Error("todo change listener lost, reconnecting", "error", err, "retry_in", backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, listenRetryMax)
+	}
+}
+
+func (s *TodoStorage) listen(ctx context.Context) error {
+	pooled, err := s.DB.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// Take the connection out of the pool: a connection still LISTENing
+	// must never be handed to a request.
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+todosChangedChannel); err != nil {
+		return err
+	}
+	return s.watch(ctx, conn)
+}
+
+// notificationWaiter is the part of *pgx.Conn watch needs.
+type notificationWaiter interface {
+	WaitForNotification(ctx context.Context) (*pgconn.Notification, error)
+}
+
+// watch clears the list cache on every notification conn receives, until
+// it fails.
+func (s *TodoStorage) watch(ctx context.Context, conn notificationWaiter) error {
+	// Anything written while we were disconnected went unnoticed.
+	s.cache.invalidate()
+	s.log.Info("listening for todo changes", "channel", todosChangedChannel)
+
+	for {
+		if _, err := conn.WaitForNotification(ctx); err != nil {
+			return err
+		}
+		s.cache.invalidate()
+	}
+}
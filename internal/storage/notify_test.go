@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/manish-npx/simple-go-echo/internal/logger"
+	"github.com/manish-npx/simple-go-echo/internal/models"
+	"github.com/manish-npx/simple-go-echo/internal/utils/queryparams"
+)
+
+// fakeListener hands out the notifications sent on notes and reports each
+// wait on waiting. Closing notes drops the connection.
+type fakeListener struct {
+	notes   chan *pgconn.Notification
+	waiting chan struct{}
+}
+
+func (l *fakeListener) WaitForNotification(ctx context.Context) (*pgconn.Notification, error) {
+	l.waiting <- struct{}{}
+	n, ok := <-l.notes
+	if !ok {
+		return nil, errors.New("connection lost")
+	}
+	return n, nil
+}
+
+func TestWatchClearsListCache(t *testing.T) {
+	s := &TodoStorage{log: logger.Nop(), cache: newListCache(time.Hour)}
+	key := listCacheKey("", queryparams.ListQuery{Sort: "id", Limit: 20})
+	seed := func() {
+		_, _, gen, _ := s.cache.get(key)
+		s.cache.set(key, gen, []models.Todo{{ID: 1, Title: "cached"}}, 1)
+		if _, _, _, ok := s.cache.get(key); !ok {
+			t.Fatal("seeded page is not cached")
+		}
+	}
+	missed := func(when string) {
+		t.Helper()
+		before := listCacheMisses.Value()
+		if _, _, _, ok := s.cache.get(key); ok {
+			t.Errorf("%s: page still cached", when)
+		}
+		if got := listCacheMisses.Value() - before; got != 1 {
+			t.Errorf("%s: todo_list_cache_misses rose by %d, want 1", when, got)
+		}
+	}
+
+	seed()
+	l := &fakeListener{notes: make(chan *pgconn.Notification), waiting: make(chan struct{})}
+	done := make(chan error, 1)
+	go func() { done <- s.watch(context.Background(), l) }()
+
+	// (Re)connecting clears pages cached while nobody was listening.
+	<-l.waiting
+	missed("after connecting")
+
+	seed()
+	l.notes <- &pgconn.Notification{Channel: todosChangedChannel}
+	<-l.waiting
+	missed("after a notification")
+
+	close(l.notes)
+	if err := <-done; err == nil {
+		t.Error("watch returned nil after the connection dropped, want its error")
+	}
+}
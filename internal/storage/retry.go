@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/manish-npx/simple-go-echo/internal/database"
+	"github.com/manish-npx/simple-go-echo/internal/logger"
+)
+
+// RetryPolicy controls how reads are retried on transient errors.
+// MaxRetries of zero disables retrying.
+type RetryPolicy struct {
+	MaxRetries int
+	Backoff    time.Duration
+}
+
+// retryable Postgres error codes: serialization_failure and deadlock_detected.
+var retryableCodes = map[string]bool{
+	"40001": true,
+	"40P01": true,
+}
+
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return retryableCodes[pgErr.Code]
+	}
+	// The query never reached the server (e.g. the connection was reset
+	// while sending), so running it again cannot apply it twice.
+	return pgconn.SafeToRetry(err)
+}
+
+// withRetry runs fn, retrying with exponential backoff while the error is
+// transient. Only use it for reads or statements inside a transaction that
+// is retried as a whole: a write that failed after reaching the server may
+// have been applied.
+//
+// A lost connection (see database.ConnectionLost) is retried once straight
+// away even when retries are disabled, since the pool hands out a fresh
+// connection and a read is safe to run again. That reconnect does not count
+// against MaxRetries.
+func withRetry[T any](ctx context.Context, p RetryPolicy, fn func() (T, error)) (T, error) {
+	backoff := p.Backoff
+	reconnected := false
+	retries := 0
+	for {
+		result, err := fn()
+		if err == nil || ctx.Err() != nil {
+			return result, err
+		}
+		if !reconnected && database.ConnectionLost(err) {
+			reconnected = true
+			logger.FromContext(ctx).Warn("database connection lost, retrying on a new connection", "error", err)
+			continue
+		}
+		if retries >= p.MaxRetries || !isRetryable(err) {
+			return result, err
+		}
+		retries++
+
+		select {
+		case <-ctx.Done():
+			return result, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
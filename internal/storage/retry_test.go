@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// failing returns a fn for withRetry that fails with errs in turn and then
+// succeeds, counting its calls.
+func failing(calls *int, errs ...error) func() (string, error) {
+	return func() (string, error) {
+		*calls++
+		if *calls <= len(errs) {
+			return "", errs[*calls-1]
+		}
+		return "ok", nil
+	}
+}
+
+func TestWithRetrySucceedsAfterTransientError(t *testing.T) {
+	var calls int
+	got, err := withRetry(context.Background(), RetryPolicy{MaxRetries: 2, Backoff: time.Millisecond},
+		failing(&calls, &pgconn.PgError{Code: "40001"}))
+	if err != nil || got != "ok" {
+		t.Fatalf("got %q, %v; want ok", got, err)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+}
+
+func TestWithRetryGivesUp(t *testing.T) {
+	deadlock := &pgconn.PgError{Code: "40P01"}
+	var calls int
+	_, err := withRetry(context.Background(), RetryPolicy{MaxRetries: 2, Backoff: time.Millisecond},
+		failing(&calls, deadlock, deadlock, deadlock, deadlock))
+	if !errors.Is(err, deadlock) {
+		t.Fatalf("err = %v, want the deadlock", err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 1 + 2 retries", calls)
+	}
+}
+
+func TestWithRetrySkipsPermanentErrors(t *testing.T) {
+	var calls int
+	_, err := withRetry(context.Background(), RetryPolicy{MaxRetries: 2, Backoff: time.Millisecond},
+		failing(&calls, &pgconn.PgError{Code: uniqueViolation}))
+	if err == nil || calls != 1 {
+		t.Errorf("calls = %d, err = %v; want one failed call", calls, err)
+	}
+}
+
+func TestWithRetryReconnectsOnceWhenDisabled(t *testing.T) {
+	var calls int
+	got, err := withRetry(context.Background(), RetryPolicy{}, failing(&calls, io.ErrUnexpectedEOF))
+	if err != nil || got != "ok" || calls != 2 {
+		t.Errorf("got %q, %v after %d calls; want ok after 2", got, err, calls)
+	}
+
+	calls = 0
+	_, err = withRetry(context.Background(), RetryPolicy{}, failing(&calls, io.ErrUnexpectedEOF, io.ErrUnexpectedEOF))
+	if err == nil || calls != 2 {
+		t.Errorf("calls = %d, err = %v; want a single reconnect", calls, err)
+	}
+}
+
+func TestWithRetryReconnectKeepsRetryBudget(t *testing.T) {
+	var calls int
+	got, err := withRetry(context.Background(), RetryPolicy{MaxRetries: 1, Backoff: time.Millisecond},
+		failing(&calls, io.ErrUnexpectedEOF, &pgconn.PgError{Code: "40001"}))
+	if err != nil || got != "ok" || calls != 3 {
+		t.Errorf("got %q, %v after %d calls; want ok after a reconnect and a retry", got, err, calls)
+	}
+}
+
+func TestWithRetryStopsWhenContextEnds(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int
+	_, err := withRetry(ctx, RetryPolicy{MaxRetries: 5, Backoff: time.Hour}, func() (string, error) {
+		calls++
+		cancel()
+		return "", &pgconn.PgError{Code: "40001"}
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("calls = %d, err = %v; want one failed call", calls, err)
+	}
+}
@@ -3,82 +3,834 @@ package storage
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/manish-npx/simple-go-echo/internal/database"
+	"github.com/manish-npx/simple-go-echo/internal/errs"
+	"github.com/manish-npx/simple-go-echo/internal/logger"
 	"github.com/manish-npx/simple-go-echo/internal/models"
+	"github.com/manish-npx/simple-go-echo/internal/utils/queryparams"
+	"github.com/manish-npx/simple-go-echo/internal/validation"
+	"golang.org/x/sync/singleflight"
 )
 
-var ErrTodoNotFound = errors.New("todo not found")
+var ErrTodoNotFound = errs.NotFound("Todo not found")
+
+// ErrTodoTooLong is returned when Postgres rejects a title or description
+// longer than its column. Request validation normally catches these first.
+var ErrTodoTooLong = errs.Invalid(fmt.Sprintf(
+	"Title or description too long: at most %d and %d characters",
+	validation.MaxTitleLength, validation.MaxDescriptionLength))
+
+// todoError maps pgx.ErrNoRows to ErrTodoNotFound and adds the operation
+// and id to anything else.
+func todoError(op string, id int64, err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrTodoNotFound
+	}
+	if valueTooLong(err) {
+		return ErrTodoTooLong
+	}
+	return fmt.Errorf("%s todo %d: %w", op, id, err)
+}
+
+const todoColumns = `id, public_id, title, description, done, external_id, created_at, updated_at`
+
+// collectTodo maps the single row of a query onto a Todo. It takes the
+// results of Query directly, e.g. collectTodo(s.db(ctx).Query(ctx, sql,
+// id)), and returns pgx.ErrNoRows when nothing matched.
+func collectTodo(rows pgx.Rows, err error) (models.Todo, error) {
+	if err != nil {
+		return models.Todo{}, err
+	}
+	return pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[models.Todo])
+}
+
+// collectTodos maps every row onto a Todo. Queries must select exactly
+// todoColumns.
+func collectTodos(rows pgx.Rows) ([]models.Todo, error) {
+	return pgx.CollectRows(rows, pgx.RowToStructByName[models.Todo])
+}
+
+// Options tunes a TodoStorage.
+type Options struct {
+	Retry RetryPolicy
+
+	// ListCacheTTL caches GetAll pages in memory for that long; any write
+	// clears the cache. Zero disables it.
+	ListCacheTTL time.Duration
+
+	// NoReturning makes writes re-select the row by id inside the same
+	// transaction instead of using RETURNING, for proxies that mishandle it.
+	NoReturning bool
+
+	// CoalesceReads lets concurrent GetByID calls for the same id share one
+	// query.
+	CoalesceReads bool
+}
 
 type TodoStorage struct {
-	DB *pgxpool.Pool
+	DB          *pgxpool.Pool
+	log         logger.Logger
+	retry       RetryPolicy
+	cache       *listCache
+	noReturning bool
+	// byID coalesces GetByID calls; nil when CoalesceReads is off.
+	byID *singleflight.Group
 }
 
-func NewTodoStorage(db *pgxpool.Pool) *TodoStorage {
-	return &TodoStorage{DB: db}
+// NewTodoStorage panics if db is nil, which is always a wiring mistake and
+// would otherwise only surface as a nil dereference inside pgx on the first
+// query.
+func NewTodoStorage(db *pgxpool.Pool, log logger.Logger, opts Options) *TodoStorage {
+	if db == nil {
+		panic("storage: NewTodoStorage called with a nil pool")
+	}
+	s := &TodoStorage{
+		DB:          db,
+		log:         log,
+		retry:       opts.Retry,
+		cache:       newListCache(opts.ListCacheTTL),
+		noReturning: opts.NoReturning,
+	}
+	if opts.CoalesceReads {
+		s.byID = new(singleflight.Group)
+	}
+	return s
+}
+
+// db returns the pool for ctx's tenant (see database.WithTenant), or DB in
+// single-database mode.
+func (s *TodoStorage) db(ctx context.Context) *pgxpool.Pool {
+	return database.PoolFor(ctx, s.DB)
 }
 
 func (s *TodoStorage) Create(ctx context.Context, todo *models.Todo) (int64, error) {
-	var id int64
-	err := s.DB.QueryRow(ctx,
-		`INSERT INTO todos (title, done) VALUES ($1, $2) RETURNING id`,
-		todo.Title, todo.Done,
-	).Scan(&id)
-	return id, err
+	defer s.cache.invalidate()
+
+	const insert = `INSERT INTO todos (title, description, done, external_id) VALUES ($1, $2, $3, $4)`
+	args := []any{todo.Title, todo.Description, todo.Done, todo.ExternalID}
+	if !s.noReturning {
+		err := s.db(ctx).QueryRow(ctx, insert+` RETURNING id, public_id, created_at, updated_at`, args...).
+			Scan(&todo.ID, &todo.PublicID, &todo.CreatedAt, &todo.UpdatedAt)
+		return todo.ID, writeError(err)
+	}
+
+	err := pgx.BeginFunc(ctx, s.db(ctx), func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, insert, args...); err != nil {
+			return err
+		}
+		// lastval() is per session, and the transaction pins one connection.
+		return tx.QueryRow(ctx,
+			`SELECT id, public_id, created_at, updated_at FROM todos WHERE id = lastval()`,
+		).Scan(&todo.ID, &todo.PublicID, &todo.CreatedAt, &todo.UpdatedAt)
+	})
+	return todo.ID, writeError(err)
 }
 
-func (s *TodoStorage) GetAll(ctx context.Context) ([]models.Todo, error) {
-	rows, err := s.DB.Query(ctx, `SELECT id, title, done FROM todos ORDER BY id`)
+// Postgres error codes for a duplicate key and for a value longer than its
+// VARCHAR column.
+const (
+	uniqueViolation = "23505"
+	stringTooLong   = "22001"
+)
+
+// writeError turns the constraint errors an insert can hit into errors
+// clients can act on: a clash on todos_external_id_key is a conflict (they
+// can upsert instead) and an over-long value is ErrTodoTooLong.
+func writeError(err error) error {
+	var pgErr *pgconn.PgError
+	switch {
+	case !errors.As(err, &pgErr):
+		return err
+	case pgErr.Code == uniqueViolation && pgErr.ConstraintName == "todos_external_id_key":
+		return errs.Conflict("A todo with this external_id already exists")
+	case pgErr.Code == stringTooLong:
+		return ErrTodoTooLong
+	}
+	return err
+}
+
+func valueTooLong(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == stringTooLong
+}
+
+// updateTodo runs an UPDATE of the row with the given id and returns the
+// row as it is afterwards. It returns pgx.ErrNoRows if no row matched.
+func (s *TodoStorage) updateTodo(ctx context.Context, id int64, sql string, args ...any) (models.Todo, error) {
+	if !s.noReturning {
+		return collectTodo(s.db(ctx).Query(ctx, sql+` RETURNING `+todoColumns, args...))
+	}
+
+	var todo models.Todo
+	err := pgx.BeginFunc(ctx, s.db(ctx), func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, sql, args...)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return pgx.ErrNoRows
+		}
+		todo, err = collectTodo(tx.Query(ctx, `SELECT `+todoColumns+` FROM todos WHERE id=$1`, id))
+		return err
+	})
+	return todo, err
+}
+
+// GetAll returns one page of todos plus the number of todos matching the
+// filters across all pages.
+func (s *TodoStorage) GetAll(ctx context.Context, q queryparams.ListQuery) ([]models.Todo, int, error) {
+	key := listCacheKey(database.Tenant(ctx), q)
+	todos, total, gen, ok := s.cache.get(key)
+	if ok {
+		return todos, total, nil
+	}
+
+	todos, total, err := s.getAll(ctx, q)
+	if err == nil {
+		s.cache.set(key, gen, todos, total)
+	}
+	return todos, total, err
+}
+
+func (s *TodoStorage) getAll(ctx context.Context, q queryparams.ListQuery) ([]models.Todo, int, error) {
+	total, err := s.CountWhere(ctx, q.Filters)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	where, args := filterClause(q.Filters)
+
+	args = append(args, q.Limit, q.Offset)
+	sql := `SELECT ` + todoColumns + ` FROM todos` + where +
+		` ORDER BY ` + orderClause(q) +
+		fmt.Sprintf(` LIMIT $%d OFFSET $%d`, len(args)-1, len(args))
+
+	todos, err := s.queryTodos(ctx, sql, args...)
+	return todos, total, err
+}
+
+// StreamAll calls fn with every todo matching q's filters, in q's order,
+// ignoring its limit and offset. Rows are decoded one at a time as they
+// arrive, so memory stays flat however many todos there are. A failed
+// query is not retried, since fn may already have passed rows on, and an
+// error from fn stops the stream and is returned as is.
+func (s *TodoStorage) StreamAll(ctx context.Context, q queryparams.ListQuery, fn func(*models.Todo) error) error {
+	where, args := filterClause(q.Filters)
+	rows, err := s.db(ctx).Query(ctx, `SELECT `+todoColumns+` FROM todos`+where+` ORDER BY `+orderClause(q), args...)
+	if err != nil {
+		return err
 	}
 	defer rows.Close()
 
-	var todos []models.Todo
 	for rows.Next() {
-		var todo models.Todo
-		if err := rows.Scan(&todo.ID, &todo.Title, &todo.Done); err != nil {
+		todo, err := pgx.RowToStructByName[models.Todo](rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(&todo); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// CountWhere counts the todos matching filters, using the same WHERE
+// clause as GetAll so counts and lists always agree.
+func (s *TodoStorage) CountWhere(ctx context.Context, filters map[string]bool) (int, error) {
+	where, args := filterClause(filters)
+	return withRetry(ctx, s.retry, func() (int, error) {
+		var n int
+		err := s.db(ctx).QueryRow(ctx, `SELECT COUNT(*) FROM todos`+where, args...).Scan(&n)
+		return n, err
+	})
+}
+
+// Stats counts todos created and completed per bucket ("day", "week" or
+// "month") in [from, to). Empty buckets are included with zero counts.
+// There is no completed_at column, so a todo counts as completed in the
+// bucket of its last update while it is done.
+func (s *TodoStorage) Stats(ctx context.Context, bucket string, from, to time.Time) ([]models.TodoStats, error) {
+	// The columns are TIMESTAMP in UTC, and pgx sends a time's wall clock
+	// for a timestamp parameter, dropping its offset.
+	from, to = from.UTC(), to.UTC()
+	return withRetry(ctx, s.retry, func() ([]models.TodoStats, error) {
+		rows, err := s.db(ctx).Query(ctx, `
+			SELECT s.bucket, COALESCE(c.n, 0), COALESCE(d.n, 0)
+			FROM generate_series(date_trunc($1, $2::timestamp), $3::timestamp, ('1 ' || $1)::interval) AS s(bucket)
+			LEFT JOIN (
+				SELECT date_trunc($1, created_at) AS bucket, count(*) AS n
+				FROM todos WHERE created_at >= $2 AND created_at < $3 GROUP BY 1
+			) c USING (bucket)
+			LEFT JOIN (
+				SELECT date_trunc($1, updated_at) AS bucket, count(*) AS n
+				FROM todos WHERE done AND updated_at >= $2 AND updated_at < $3 GROUP BY 1
+			) d USING (bucket)
+			WHERE s.bucket < $3
+			ORDER BY s.bucket`,
+			bucket, from, to,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.TodoStats, error) {
+			var st models.TodoStats
+			err := row.Scan(&st.Bucket, &st.Created, &st.Completed)
+			return st, err
+		})
+	})
+}
+
+// GetGrouped returns one page of pending and one page of done todos from a
+// single query, so the two lists reflect the same snapshot. Limit and
+// Offset apply to each group separately.
+func (s *TodoStorage) GetGrouped(ctx context.Context, q queryparams.ListQuery) (pending, done []models.Todo, err error) {
+	order := orderClause(q)
+	todos, err := s.queryTodos(ctx,
+		`SELECT `+todoColumns+` FROM (
+			SELECT `+todoColumns+`, row_number() OVER (PARTITION BY done ORDER BY `+order+`) AS rn
+			FROM todos
+		) t
+		WHERE rn > $1 AND rn <= $1 + $2
+		ORDER BY done, rn`,
+		q.Offset, q.Limit,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pending, done = []models.Todo{}, []models.Todo{}
+	for _, todo := range todos {
+		if todo.Done {
+			done = append(done, todo)
+		} else {
+			pending = append(pending, todo)
+		}
+	}
+	return pending, done, nil
+}
+
+// orderClause renders the sort column and direction followed by the
+// tie-breaker, without the ORDER BY keyword.
+func orderClause(q queryparams.ListQuery) string {
+	direction := "ASC"
+	if q.Desc {
+		direction = "DESC"
+	}
+	order := pgx.Identifier{q.Sort}.Sanitize() + ` ` + direction
+	if q.TieBreaker != "" && q.TieBreaker != q.Sort {
+		order += `, ` + pgx.Identifier{q.TieBreaker}.Sanitize() + ` ASC`
+	}
+	return order
+}
+
+// filterClause builds a WHERE clause matching every filter by equality.
+// Column names come from queryparams.Options.BoolFilters, never the client.
+func filterClause(filters map[string]bool) (string, []any) {
+	if len(filters) == 0 {
+		return "", nil
+	}
+
+	var (
+		where []string
+		args  []any
+	)
+	for name, value := range filters {
+		args = append(args, value)
+		where = append(where, fmt.Sprintf("%s = $%d", pgx.Identifier{name}.Sanitize(), len(args)))
+	}
+	return ` WHERE ` + strings.Join(where, " AND "), args
+}
+
+// queryTodos runs a read query, retrying transient failures.
+func (s *TodoStorage) queryTodos(ctx context.Context, sql string, args ...any) ([]models.Todo, error) {
+	return withRetry(ctx, s.retry, func() ([]models.Todo, error) {
+		rows, err := s.db(ctx).Query(ctx, sql, args...)
+		if err != nil {
 			return nil, err
 		}
-		todos = append(todos, todo)
+		return collectTodos(rows)
+	})
+}
+
+// LastModified returns the newest updated_at across all todos and the row
+// count. The count changes on delete, which updated_at alone would miss.
+func (s *TodoStorage) LastModified(ctx context.Context) (time.Time, int64, error) {
+	type stamp struct {
+		lastModified time.Time
+		count        int64
+	}
+	st, err := withRetry(ctx, s.retry, func() (stamp, error) {
+		var st stamp
+		err := s.db(ctx).QueryRow(ctx,
+			`SELECT COALESCE(MAX(updated_at), 'epoch'::timestamp), COUNT(*) FROM todos`,
+		).Scan(&st.lastModified, &st.count)
+		return st, err
+	})
+	return st.lastModified, st.count, err
+}
+
+// GetRecent returns the most recently changed todos, newest first.
+func (s *TodoStorage) GetRecent(ctx context.Context, limit int) ([]models.Todo, error) {
+	return s.queryTodos(ctx,
+		`SELECT `+todoColumns+` FROM todos ORDER BY updated_at DESC, id DESC LIMIT $1`,
+		limit,
+	)
+}
+
+// ExistsByTitle reports whether a todo with the same title exists,
+// ignoring case.
+func (s *TodoStorage) ExistsByTitle(ctx context.Context, title string) (bool, error) {
+	return withRetry(ctx, s.retry, func() (bool, error) {
+		var exists bool
+		err := s.db(ctx).QueryRow(ctx,
+			`SELECT EXISTS (SELECT 1 FROM todos WHERE lower(title) = lower($1))`,
+			title,
+		).Scan(&exists)
+		return exists, err
+	})
+}
+
+// ExistsByExternalID reports whether a todo with this external_id exists.
+func (s *TodoStorage) ExistsByExternalID(ctx context.Context, externalID string) (bool, error) {
+	return withRetry(ctx, s.retry, func() (bool, error) {
+		var exists bool
+		err := s.db(ctx).QueryRow(ctx,
+			`SELECT EXISTS (SELECT 1 FROM todos WHERE external_id = $1)`,
+			externalID,
+		).Scan(&exists)
+		return exists, err
+	})
+}
+
+// GetByIDs returns the todos with the given ids in the order the ids were
+// given. Unknown ids are skipped.
+func (s *TodoStorage) GetByIDs(ctx context.Context, ids []int64) ([]models.Todo, error) {
+	todos, err := s.queryTodos(ctx,
+		`SELECT `+todoColumns+` FROM todos WHERE id = ANY($1)`,
+		ids,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int64]models.Todo, len(todos))
+	for _, t := range todos {
+		byID[t.ID] = t
+	}
+	ordered := make([]models.Todo, 0, len(todos))
+	for _, id := range ids {
+		if t, ok := byID[id]; ok {
+			ordered = append(ordered, t)
+		}
 	}
-	//find all the todos rows
-	/*     todos, err := pgx.CollectRows(rows, pgx.RowToStructByName[models.Todo]) */
+	return ordered, nil
+}
 
-	return todos, nil
+// adjacentRow is a Todo plus which side of the requested todo it is on.
+type adjacentRow struct {
+	models.Todo
+	Side string `db:"side"`
+}
+
+// GetAdjacent returns the todos just before and after id in the order and
+// filters of q; its limit and offset are ignored. prev or next is nil at
+// either end of the list. If id is missing or filtered out it returns
+// ErrTodoNotFound.
+//
+// Each neighbour is a keyset lookup from id's row with LIMIT 1, so neither
+// side numbers or sorts the whole list.
+func (s *TodoStorage) GetAdjacent(ctx context.Context, id int64, q queryparams.ListQuery) (prev, next *models.Todo, err error) {
+	where, args := filterClause(q.Filters)
+	and := ` WHERE `
+	if where != "" {
+		and = ` AND `
+	}
+	args = append(args, id)
+	before, beforeOrder := keysetClause(q, true)
+	after, afterOrder := keysetClause(q, false)
+	sql := `WITH anchor AS (
+			SELECT ` + todoColumns + ` FROM todos` + where + and + `id = $` + strconv.Itoa(len(args)) + `
+		)
+		SELECT 'self' AS side, ` + todoColumns + ` FROM anchor
+		UNION ALL
+		(SELECT 'prev', ` + todoColumns + ` FROM todos` + where + and + before + ` ORDER BY ` + beforeOrder + ` LIMIT 1)
+		UNION ALL
+		(SELECT 'next', ` + todoColumns + ` FROM todos` + where + and + after + ` ORDER BY ` + afterOrder + ` LIMIT 1)`
+
+	rows, err := withRetry(ctx, s.retry, func() ([]adjacentRow, error) {
+		rows, err := s.db(ctx).Query(ctx, sql, args...)
+		if err != nil {
+			return nil, err
+		}
+		return pgx.CollectRows(rows, pgx.RowToStructByName[adjacentRow])
+	})
+	if err != nil {
+		return nil, nil, todoError("get adjacent to", id, err)
+	}
+
+	found := false
+	for i := range rows {
+		switch rows[i].Side {
+		case "self":
+			found = true
+		case "prev":
+			prev = &rows[i].Todo
+		case "next":
+			next = &rows[i].Todo
+		}
+	}
+	if !found {
+		return nil, nil, ErrTodoNotFound
+	}
+	return prev, next, nil
+}
+
+// keysetClause renders the condition for the rows after the anchor CTE's
+// row in q's order, or before it, and the ORDER BY that walks away from it,
+// so the first row is the nearest one. It mirrors orderClause.
+func keysetClause(q queryparams.ListQuery, before bool) (cond, order string) {
+	sort := pgx.Identifier{q.Sort}.Sanitize()
+	op, dir := ">", "ASC"
+	if q.Desc != before {
+		op, dir = "<", "DESC"
+	}
+	if q.TieBreaker == "" || q.TieBreaker == q.Sort {
+		return fmt.Sprintf(`%s %s (SELECT %[1]s FROM anchor)`, sort, op), sort + ` ` + dir
+	}
+
+	// The tie-breaker always sorts ascending in the list.
+	tie := pgx.Identifier{q.TieBreaker}.Sanitize()
+	tieOp, tieDir := ">", "ASC"
+	if before {
+		tieOp, tieDir = "<", "DESC"
+	}
+	order = sort + ` ` + dir + `, ` + tie + ` ` + tieDir
+	if op == tieOp {
+		return fmt.Sprintf(`(%s, %s) %s (SELECT %[1]s, %[2]s FROM anchor)`, sort, tie, op), order
+	}
+	return fmt.Sprintf(`(%s %s (SELECT %[1]s FROM anchor) OR (%[1]s = (SELECT %[1]s FROM anchor) AND %[3]s %[4]s (SELECT %[3]s FROM anchor)))`,
+		sort, op, tie, tieOp), order
+}
+
+// upsertRow is a Todo plus whether the upsert inserted it.
+type upsertRow struct {
+	models.Todo
+	Inserted bool `db:"inserted"`
+}
+
+// Upsert creates the todo with todo.ExternalID, or overwrites the title,
+// description and done of the one that already has it. created reports
+// which happened.
+func (s *TodoStorage) Upsert(ctx context.Context, todo *models.Todo) (result *models.Todo, created bool, err error) {
+	defer s.cache.invalidate()
+
+	const upsert = `INSERT INTO todos (external_id, title, description, done) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (external_id) DO UPDATE
+		SET title = EXCLUDED.title, description = EXCLUDED.description, done = EXCLUDED.done,
+			updated_at = CURRENT_TIMESTAMP`
+	args := []any{todo.ExternalID, todo.Title, todo.Description, todo.Done}
+
+	if !s.noReturning {
+		// xmax is 0 only on a freshly inserted row version.
+		rows, err := s.db(ctx).Query(ctx, upsert+` RETURNING `+todoColumns+`, xmax = 0 AS inserted`, args...)
+		if err != nil {
+			return nil, false, writeError(err)
+		}
+		row, err := pgx.CollectExactlyOneRow(rows, pgx.RowToStructByName[upsertRow])
+		if err != nil {
+			return nil, false, writeError(err)
+		}
+		return &row.Todo, row.Inserted, nil
+	}
+
+	var upserted models.Todo
+	err = pgx.BeginFunc(ctx, s.db(ctx), func(tx pgx.Tx) error {
+		// Checked separately, so a concurrent insert of the same
+		// external_id can be reported as created by both callers.
+		var exists bool
+		if err := tx.QueryRow(ctx,
+			`SELECT EXISTS (SELECT 1 FROM todos WHERE external_id = $1)`, todo.ExternalID,
+		).Scan(&exists); err != nil {
+			return err
+		}
+		created = !exists
+
+		if _, err := tx.Exec(ctx, upsert, args...); err != nil {
+			return err
+		}
+		upserted, err = collectTodo(tx.Query(ctx,
+			`SELECT `+todoColumns+` FROM todos WHERE external_id = $1`, todo.ExternalID,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, false, writeError(err)
+	}
+	return &upserted, created, nil
 }
 
 func (s *TodoStorage) GetByID(ctx context.Context, id int64) (*models.Todo, error) {
-	var todo models.Todo
-	err := s.DB.QueryRow(ctx,
-		`SELECT id, title, done FROM todos WHERE id=$1`,
-		id,
-	).Scan(&todo.ID, &todo.Title, &todo.Done)
+	var (
+		todo models.Todo
+		err  error
+	)
+	if s.byID != nil {
+		todo, err = s.getByIDShared(ctx, id, s.getByID)
+	} else {
+		todo, err = s.getByID(ctx, id)
+	}
 
 	if err != nil {
-		return nil, ErrTodoNotFound
+		return nil, todoError("get", id, err)
 	}
 	return &todo, nil
 }
 
+// IDByPublicID returns the id of the todo whose public_id is publicID,
+// which must be a well-formed UUID, or ErrTodoNotFound.
+func (s *TodoStorage) IDByPublicID(ctx context.Context, publicID string) (int64, error) {
+	id, err := withRetry(ctx, s.retry, func() (int64, error) {
+		var id int64
+		err := s.db(ctx).QueryRow(ctx, `SELECT id FROM todos WHERE public_id=$1`, publicID).Scan(&id)
+		return id, err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrTodoNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("get todo %s: %w", publicID, err)
+	}
+	return id, nil
+}
+
+func (s *TodoStorage) getByID(ctx context.Context, id int64) (models.Todo, error) {
+	return withRetry(ctx, s.retry, func() (models.Todo, error) {
+		return collectTodo(s.db(ctx).Query(ctx,
+			`SELECT `+todoColumns+` FROM todos WHERE id=$1`,
+			id,
+		))
+	})
+}
+
+// getByIDShared joins an identical fetch already in flight, if any. The
+// shared fetch ignores cancellation, so one caller hanging up doesn't fail
+// the others, but keeps the first caller's deadline; each caller still
+// stops waiting when its own context ends.
+func (s *TodoStorage) getByIDShared(ctx context.Context, id int64, fetch func(context.Context, int64) (models.Todo, error)) (models.Todo, error) {
+	key := database.Tenant(ctx) + "|" + strconv.FormatInt(id, 10)
+	ch := s.byID.DoChan(key, func() (any, error) {
+		shared := context.WithoutCancel(ctx)
+		if deadline, ok := ctx.Deadline(); ok {
+			var cancel context.CancelFunc
+			shared, cancel = context.WithDeadline(shared, deadline)
+			defer cancel()
+		}
+		return fetch(shared, id)
+	})
+
+	select {
+	case <-ctx.Done():
+		return models.Todo{}, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return models.Todo{}, res.Err
+		}
+		return res.Val.(models.Todo), nil
+	}
+}
+
 func (s *TodoStorage) Update(ctx context.Context, id int64, todo *models.Todo) (*models.Todo, error) {
-	var updated models.Todo
-	err := s.DB.QueryRow(ctx,
-		`UPDATE todos SET title=$1, done=$2 WHERE id=$3 RETURNING id, title, done`,
-		todo.Title, todo.Done, id,
-	).Scan(&updated.ID, &updated.Title, &updated.Done)
+	defer s.cache.invalidate()
+
+	updated, err := s.updateTodo(ctx, id,
+		`UPDATE todos SET title=$1, description=$2, done=$3, updated_at=CURRENT_TIMESTAMP WHERE id=$4`,
+		todo.Title, todo.Description, todo.Done, id,
+	)
 
 	if err != nil {
-		return nil, ErrTodoNotFound
+		return nil, todoError("update", id, err)
 	}
 	return &updated, nil
 }
 
-func (s *TodoStorage) Delete(ctx context.Context, id int64) error {
-	result, err := s.DB.Exec(ctx, `DELETE FROM todos WHERE id=$1`, id)
+// Patch applies a partial update and reports which fields changed. The row
+// is locked while comparing, and a patch that changes nothing leaves the
+// row (including updated_at) untouched.
+func (s *TodoStorage) Patch(ctx context.Context, id int64, patch models.TodoPatch) (*models.Todo, []string, error) {
+	defer s.cache.invalidate()
+
+	var (
+		todo    models.Todo
+		changed []string
+	)
+	err := pgx.BeginFunc(ctx, s.db(ctx), func(tx pgx.Tx) error {
+		var err error
+		todo, err = collectTodo(tx.Query(ctx,
+			`SELECT `+todoColumns+` FROM todos WHERE id=$1 FOR UPDATE`,
+			id,
+		))
+		if err != nil {
+			return err
+		}
+
+		changed = patch.Apply(&todo)
+		if len(changed) == 0 {
+			return nil
+		}
+
+		if _, err := tx.Exec(ctx,
+			`UPDATE todos SET title=$1, description=$2, done=$3, updated_at=CURRENT_TIMESTAMP WHERE id=$4`,
+			todo.Title, todo.Description, todo.Done, id,
+		); err != nil {
+			return err
+		}
+		todo, err = collectTodo(tx.Query(ctx, `SELECT `+todoColumns+` FROM todos WHERE id=$1`, id))
+		return err
+	})
+
+	if err != nil {
+		return nil, nil, todoError("patch", id, err)
+	}
+	return &todo, changed, nil
+}
+
+// ToggleDone flips the done flag and returns the updated todo.
+func (s *TodoStorage) ToggleDone(ctx context.Context, id int64) (*models.Todo, error) {
+	defer s.cache.invalidate()
+
+	updated, err := s.updateTodo(ctx, id,
+		`UPDATE todos SET done = NOT done, updated_at=CURRENT_TIMESTAMP WHERE id=$1`,
+		id,
+	)
+
 	if err != nil {
+		return nil, todoError("toggle", id, err)
+	}
+	return &updated, nil
+}
+
+// CheckSchema confirms the migrations this storage relies on were applied
+// to ctx's database: every column it selects, the external_id unique index
+// upserts need and, with notify, the todos_changed trigger.
+func (s *TodoStorage) CheckSchema(ctx context.Context, notify bool) error {
+	db := s.db(ctx)
+	if _, err := db.Exec(ctx, `SELECT `+todoColumns+` FROM todos LIMIT 0`); err != nil {
+		return fmt.Errorf("todos table (migrations 0001-0003, 0005, 0007): %w", err)
+	}
+
+	var ok bool
+	if err := db.QueryRow(ctx, `SELECT to_regclass('todos_external_id_key') IS NOT NULL`).Scan(&ok); err != nil {
 		return err
 	}
+	if !ok {
+		return errors.New("index todos_external_id_key missing (migration 0005)")
+	}
+
+	var descLen *int
+	if err := db.QueryRow(ctx,
+		`SELECT character_maximum_length FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = 'todos' AND column_name = 'description'`,
+	).Scan(&descLen); err != nil {
+		return err
+	}
+	if descLen == nil {
+		return errors.New("column todos.description has no length limit (migration 0006)")
+	}
+
+	if notify {
+		if err := db.QueryRow(ctx,
+			`SELECT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'todos_changed' AND tgrelid = 'todos'::regclass)`,
+		).Scan(&ok); err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("trigger todos_changed missing (migration 0004)")
+		}
+	}
+	return nil
+}
+
+// copySuffix is appended to the title of a duplicated todo.
+const copySuffix = " (copy)"
+
+// Duplicate inserts a copy of the todo with the given id, not done and with
+// copySuffix appended to its title, and returns it. The original title is
+// shortened if needed to fit the column. external_id is not copied, since
+// it identifies the original. It returns ErrTodoNotFound if there is no
+// such todo.
+func (s *TodoStorage) Duplicate(ctx context.Context, id int64) (*models.Todo, error) {
+	defer s.cache.invalidate()
+
+	// One statement, so the copy is of a consistent row.
+	insert := fmt.Sprintf(`INSERT INTO todos (title, description, done)
+		SELECT left(title, %d) || $2, description, false FROM todos WHERE id=$1`,
+		validation.MaxTitleLength-len(copySuffix))
+
+	var (
+		todo models.Todo
+		err  error
+	)
+	if !s.noReturning {
+		todo, err = collectTodo(s.db(ctx).Query(ctx, insert+` RETURNING `+todoColumns, id, copySuffix))
+	} else {
+		err = pgx.BeginFunc(ctx, s.db(ctx), func(tx pgx.Tx) error {
+			tag, err := tx.Exec(ctx, insert, id, copySuffix)
+			if err != nil {
+				return err
+			}
+			if tag.RowsAffected() == 0 {
+				return pgx.ErrNoRows
+			}
+			todo, err = collectTodo(tx.Query(ctx, `SELECT `+todoColumns+` FROM todos WHERE id = lastval()`))
+			return err
+		})
+	}
+
+	if err != nil {
+		return nil, todoError("duplicate", id, err)
+	}
+	return &todo, nil
+}
+
+// SetAllDone sets done on every todo that isn't already in that state and
+// returns how many rows changed.
+func (s *TodoStorage) SetAllDone(ctx context.Context, done bool) (int64, error) {
+	defer s.cache.invalidate()
+
+	result, err := s.db(ctx).Exec(ctx,
+		`UPDATE todos SET done=$1, updated_at=CURRENT_TIMESTAMP WHERE done IS DISTINCT FROM $1`,
+		done,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+// PreviewSetAllDone returns the todos SetAllDone(done) would change,
+// without modifying anything.
+func (s *TodoStorage) PreviewSetAllDone(ctx context.Context, done bool) ([]models.Todo, error) {
+	return s.queryTodos(ctx,
+		`SELECT `+todoColumns+` FROM todos WHERE done IS DISTINCT FROM $1 ORDER BY id`,
+		done,
+	)
+}
+
+func (s *TodoStorage) Delete(ctx context.Context, id int64) error {
+	defer s.cache.invalidate()
+
+	result, err := s.db(ctx).Exec(ctx, `DELETE FROM todos WHERE id=$1`, id)
+	if err != nil {
+		return todoError("delete", id, err)
+	}
 	if result.RowsAffected() == 0 {
 		return ErrTodoNotFound
 	}
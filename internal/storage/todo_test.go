@@ -0,0 +1,421 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/manish-npx/simple-go-echo/internal/errs"
+	"github.com/manish-npx/simple-go-echo/internal/logger"
+	"github.com/manish-npx/simple-go-echo/internal/models"
+	"github.com/manish-npx/simple-go-echo/internal/utils/queryparams"
+	"github.com/manish-npx/simple-go-echo/internal/validation"
+	"golang.org/x/sync/singleflight"
+)
+
+// blockingFetch counts its calls and blocks each until release is closed.
+type blockingFetch struct {
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (f *blockingFetch) fetch(ctx context.Context, id int64) (models.Todo, error) {
+	f.calls.Add(1)
+	select {
+	case <-f.release:
+		return models.Todo{ID: id, Title: "shared"}, nil
+	case <-ctx.Done():
+		return models.Todo{}, ctx.Err()
+	}
+}
+
+func TestGetByIDSharedCoalesces(t *testing.T) {
+	const callers = 50
+	s := &TodoStorage{byID: new(singleflight.Group)}
+	f := &blockingFetch{release: make(chan struct{})}
+
+	var (
+		wg    sync.WaitGroup
+		ready sync.WaitGroup
+	)
+	results := make([]models.Todo, callers)
+	errs := make([]error, callers)
+	for i := range callers {
+		wg.Add(1)
+		ready.Add(1)
+		go func() {
+			defer wg.Done()
+			ready.Done()
+			results[i], errs[i] = s.getByIDShared(context.Background(), 7, f.fetch)
+		}()
+	}
+	ready.Wait()
+	// Give every caller time to join the fetch in flight before it ends.
+	time.Sleep(50 * time.Millisecond)
+	close(f.release)
+	wg.Wait()
+
+	if n := f.calls.Load(); n != 1 {
+		t.Errorf("fetched %d times, want 1", n)
+	}
+	for i := range callers {
+		if errs[i] != nil || results[i].ID != 7 || results[i].Title != "shared" {
+			t.Errorf("caller %d got %+v, %v", i, results[i], errs[i])
+		}
+	}
+}
+
+func TestGetByIDSharedCallerCancelDoesNotFailOthers(t *testing.T) {
+	s := &TodoStorage{byID: new(singleflight.Group)}
+	f := &blockingFetch{release: make(chan struct{})}
+
+	first := make(chan error, 1)
+	go func() {
+		_, err := s.getByIDShared(context.Background(), 7, f.fetch)
+		first <- err
+	}()
+	for f.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := s.getByIDShared(ctx, 7, f.fetch); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled caller: err = %v, want context.Canceled", err)
+	}
+
+	close(f.release)
+	if err := <-first; err != nil {
+		t.Errorf("other caller: err = %v, want nil", err)
+	}
+	if n := f.calls.Load(); n != 1 {
+		t.Errorf("fetched %d times, want 1", n)
+	}
+}
+
+func TestConstructorsRejectNilPool(t *testing.T) {
+	tests := []struct {
+		name string
+		new  func()
+		want string
+	}{
+		{"todo", func() { NewTodoStorage(nil, logger.Nop(), Options{}) }, "storage: NewTodoStorage called with a nil pool"},
+		{"blog", func() { NewBlogStorage(nil) }, "storage: NewBlogStorage called with a nil pool"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != tt.want {
+					t.Errorf("panic = %v, want %q", r, tt.want)
+				}
+			}()
+			tt.new()
+		})
+	}
+}
+
+func TestWriteErrorMapsDatabaseErrors(t *testing.T) {
+	tooLong := &pgconn.PgError{Code: stringTooLong, Message: "value too long for type character varying(255)"}
+	tests := []struct {
+		name string
+		err  error
+		kind error
+	}{
+		{"too long", tooLong, errs.ErrValidation},
+		{"too long, wrapped", fmt.Errorf("insert: %w", tooLong), errs.ErrValidation},
+		{"duplicate external_id", &pgconn.PgError{Code: uniqueViolation, ConstraintName: "todos_external_id_key"}, errs.ErrConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := writeError(tt.err); !errors.Is(err, tt.kind) {
+				t.Errorf("writeError = %v, want kind %v", err, tt.kind)
+			}
+		})
+	}
+
+	if err := writeError(tooLong); err != ErrTodoTooLong {
+		t.Errorf("writeError = %v, want ErrTodoTooLong", err)
+	}
+	if err := todoError("update", 1, tooLong); err != ErrTodoTooLong {
+		t.Errorf("todoError = %v, want ErrTodoTooLong", err)
+	}
+	other := &pgconn.PgError{Code: "23502"}
+	if err := writeError(other); err != other {
+		t.Errorf("writeError = %v, want other errors unchanged", err)
+	}
+}
+
+func TestKeysetClause(t *testing.T) {
+	tests := []struct {
+		name                string
+		q                   queryparams.ListQuery
+		before, beforeOrder string
+		after, afterOrder   string
+	}{
+		{
+			name:   "by id",
+			q:      queryparams.ListQuery{Sort: "id", TieBreaker: "id"},
+			before: `"id" < (SELECT "id" FROM anchor)`, beforeOrder: `"id" DESC`,
+			after: `"id" > (SELECT "id" FROM anchor)`, afterOrder: `"id" ASC`,
+		},
+		{
+			name:   "by title",
+			q:      queryparams.ListQuery{Sort: "title", TieBreaker: "id"},
+			before: `("title", "id") < (SELECT "title", "id" FROM anchor)`, beforeOrder: `"title" DESC, "id" DESC`,
+			after: `("title", "id") > (SELECT "title", "id" FROM anchor)`, afterOrder: `"title" ASC, "id" ASC`,
+		},
+		{
+			// Ties still run by ascending id, so the row comparison can't
+			// be used.
+			name:        "by title descending",
+			q:           queryparams.ListQuery{Sort: "title", Desc: true, TieBreaker: "id"},
+			before:      `("title" > (SELECT "title" FROM anchor) OR ("title" = (SELECT "title" FROM anchor) AND "id" < (SELECT "id" FROM anchor)))`,
+			beforeOrder: `"title" ASC, "id" DESC`,
+			after:       `("title" < (SELECT "title" FROM anchor) OR ("title" = (SELECT "title" FROM anchor) AND "id" > (SELECT "id" FROM anchor)))`,
+			afterOrder:  `"title" DESC, "id" ASC`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if cond, order := keysetClause(tt.q, true); cond != tt.before || order != tt.beforeOrder {
+				t.Errorf("before = %s ORDER BY %s\nwant %s ORDER BY %s", cond, order, tt.before, tt.beforeOrder)
+			}
+			if cond, order := keysetClause(tt.q, false); cond != tt.after || order != tt.afterOrder {
+				t.Errorf("after = %s ORDER BY %s\nwant %s ORDER BY %s", cond, order, tt.after, tt.afterOrder)
+			}
+		})
+	}
+}
+
+func TestGetAdjacent(t *testing.T) {
+	s := testStorage(t, Options{})
+	ctx := context.Background()
+	// By title: a(1), b(0), b(2), c(3).
+	todos := createTodos(t, s, "b", "a", "b", "c")
+	byID := queryparams.ListQuery{Sort: "id", TieBreaker: "id"}
+	byTitle := queryparams.ListQuery{Sort: "title", TieBreaker: "id"}
+	byTitleDesc := queryparams.ListQuery{Sort: "title", Desc: true, TieBreaker: "id"}
+
+	idOf := func(todo *models.Todo) int64 {
+		if todo == nil {
+			return 0
+		}
+		return todo.ID
+	}
+	tests := []struct {
+		name       string
+		q          queryparams.ListQuery
+		at         int
+		prev, next int64
+	}{
+		{"first has no prev", byID, 0, 0, todos[1].ID},
+		{"last has no next", byID, 3, todos[2].ID, 0},
+		{"middle", byID, 1, todos[0].ID, todos[2].ID},
+		{"first by title", byTitle, 1, 0, todos[0].ID},
+		{"equal titles by id", byTitle, 0, todos[1].ID, todos[2].ID},
+		{"last by title", byTitle, 3, todos[2].ID, 0},
+		{"first by title descending", byTitleDesc, 3, 0, todos[0].ID},
+		{"equal titles descending", byTitleDesc, 2, todos[0].ID, todos[1].ID},
+		{"last by title descending", byTitleDesc, 1, todos[2].ID, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev, next, err := s.GetAdjacent(ctx, todos[tt.at].ID, tt.q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if idOf(prev) != tt.prev || idOf(next) != tt.next {
+				t.Errorf("prev, next = %d, %d; want %d, %d", idOf(prev), idOf(next), tt.prev, tt.next)
+			}
+		})
+	}
+
+	t.Run("unknown id", func(t *testing.T) {
+		if _, _, err := s.GetAdjacent(ctx, todos[3].ID+100, byID); !errors.Is(err, ErrTodoNotFound) {
+			t.Errorf("err = %v, want ErrTodoNotFound", err)
+		}
+	})
+	t.Run("filtered out", func(t *testing.T) {
+		done := byID
+		done.Filters = map[string]bool{"done": true}
+		if _, _, err := s.GetAdjacent(ctx, todos[0].ID, done); !errors.Is(err, ErrTodoNotFound) {
+			t.Errorf("err = %v, want ErrTodoNotFound", err)
+		}
+	})
+}
+
+func TestUpsertUpdatesInsteadOfDuplicating(t *testing.T) {
+	for _, noReturning := range []bool{false, true} {
+		t.Run(fmt.Sprintf("NoReturning=%v", noReturning), func(t *testing.T) {
+			s := testStorage(t, Options{NoReturning: noReturning})
+			ctx := context.Background()
+			ext := "ext-1"
+
+			first, created, err := s.Upsert(ctx, &models.Todo{Title: "Draft", ExternalID: &ext})
+			if err != nil || !created {
+				t.Fatalf("first upsert: created = %v, err = %v; want created", created, err)
+			}
+			second, created, err := s.Upsert(ctx, &models.Todo{Title: "Final", Done: true, ExternalID: &ext})
+			if err != nil || created {
+				t.Fatalf("second upsert: created = %v, err = %v; want updated", created, err)
+			}
+			if second.ID != first.ID || second.Title != "Final" || !second.Done {
+				t.Errorf("second upsert = %+v, want todo %d updated", second, first.ID)
+			}
+			if n, err := s.CountWhere(ctx, nil); err != nil || n != 1 {
+				t.Errorf("count = %d, %v; want 1", n, err)
+			}
+		})
+	}
+}
+
+func TestDuplicate(t *testing.T) {
+	for _, noReturning := range []bool{false, true} {
+		t.Run(fmt.Sprintf("NoReturning=%v", noReturning), func(t *testing.T) {
+			s := testStorage(t, Options{NoReturning: noReturning})
+			ctx := context.Background()
+			desc, ext := "two litres", "ext-1"
+			original := models.Todo{Title: "Buy milk", Description: &desc, Done: true, ExternalID: &ext}
+			if _, err := s.Create(ctx, &original); err != nil {
+				t.Fatal(err)
+			}
+
+			clone, err := s.Duplicate(ctx, original.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch {
+			case clone.ID == original.ID || clone.PublicID == original.PublicID:
+				t.Errorf("clone has ids %d/%s, want new ones", clone.ID, clone.PublicID)
+			case clone.Title != "Buy milk (copy)" || clone.Description == nil || *clone.Description != desc:
+				t.Errorf("clone = %q/%v, want the title and description copied", clone.Title, clone.Description)
+			case bool(clone.Done) || clone.ExternalID != nil:
+				t.Errorf("clone done = %v, external_id = %v; want false and nil", clone.Done, clone.ExternalID)
+			}
+
+			if _, err := s.Update(ctx, clone.ID, &models.Todo{Title: "Buy oat milk"}); err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.GetByID(ctx, original.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Title != "Buy milk" || got.Description == nil || *got.Description != desc || !got.Done {
+				t.Errorf("original = %+v after editing the clone, want it unchanged", got)
+			}
+
+			if _, err := s.Duplicate(ctx, clone.ID+100); !errors.Is(err, ErrTodoNotFound) {
+				t.Errorf("missing id: err = %v, want ErrTodoNotFound", err)
+			}
+		})
+	}
+}
+
+func TestDuplicateShortensLongTitles(t *testing.T) {
+	s := testStorage(t, Options{})
+	long := createTodos(t, s, strings.Repeat("x", validation.MaxTitleLength))[0]
+
+	clone, err := s.Duplicate(context.Background(), long.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clone.Title) != validation.MaxTitleLength || !strings.HasSuffix(clone.Title, copySuffix) {
+		t.Errorf("clone title is %d characters ending %q, want %d ending %q",
+			len(clone.Title), clone.Title[max(0, len(clone.Title)-10):], validation.MaxTitleLength, copySuffix)
+	}
+}
+
+func TestStatsBoundsWithOffset(t *testing.T) {
+	s := testStorage(t, Options{})
+	ctx := context.Background()
+	todo := createTodos(t, s, "New year")[0]
+	if _, err := s.DB.Exec(ctx, `UPDATE todos SET created_at = '2024-01-01 00:30:00' WHERE id = $1`, todo.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Midnight UTC, written at +05:30. Read as 05:30 it would miss the todo.
+	ist := time.FixedZone("IST", 5*60*60+30*60)
+	from := time.Date(2024, 1, 1, 5, 30, 0, 0, ist)
+	stats, err := s.Stats(ctx, "day", from, from.Add(24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if len(stats) != 1 || !stats[0].Bucket.Equal(want) || stats[0].Created != 1 {
+		t.Errorf("stats = %+v, want one bucket at %v with the todo created", stats, want)
+	}
+}
+
+func TestOrderClauseAddsTieBreaker(t *testing.T) {
+	tests := []struct {
+		q    queryparams.ListQuery
+		want string
+	}{
+		{queryparams.ListQuery{Sort: "title", TieBreaker: "id"}, `"title" ASC, "id" ASC`},
+		{queryparams.ListQuery{Sort: "title", Desc: true, TieBreaker: "id"}, `"title" DESC, "id" ASC`},
+		{queryparams.ListQuery{Sort: "id", Desc: true, TieBreaker: "id"}, `"id" DESC`},
+	}
+	for _, tt := range tests {
+		if got := orderClause(tt.q); got != tt.want {
+			t.Errorf("orderClause(%+v) = %s, want %s", tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestGetAllPagesThroughEqualSortValues(t *testing.T) {
+	s := testStorage(t, Options{})
+	ctx := context.Background()
+	todos := createTodos(t, s, "same", "same", "same", "same", "same", "same", "same")
+
+	for _, desc := range []bool{false, true} {
+		t.Run(fmt.Sprintf("Desc=%v", desc), func(t *testing.T) {
+			var ids []int64
+			for offset := 0; offset < len(todos); offset += 3 {
+				page, total, err := s.GetAll(ctx, queryparams.ListQuery{Sort: "title", Desc: desc, TieBreaker: "id", Limit: 3, Offset: offset})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if total != len(todos) {
+					t.Fatalf("total = %d, want %d", total, len(todos))
+				}
+				for _, todo := range page {
+					ids = append(ids, todo.ID)
+				}
+			}
+
+			// Equal titles fall back to ascending id, so the pages neither
+			// overlap nor skip a row.
+			want := make([]int64, len(todos))
+			for i, todo := range todos {
+				want[i] = todo.ID
+			}
+			if !slices.Equal(ids, want) {
+				t.Errorf("paged ids = %v, want %v", ids, want)
+			}
+		})
+	}
+}
+
+func TestExistsByTitleIgnoresCase(t *testing.T) {
+	s := testStorage(t, Options{})
+	createTodos(t, s, "Buy milk")
+
+	for title, want := range map[string]bool{
+		"Buy milk":  true,
+		"buy MILK":  true,
+		"Buy bread": false,
+		"Buy milk ": false,
+	} {
+		got, err := s.ExistsByTitle(context.Background(), title)
+		if err != nil || got != want {
+			t.Errorf("ExistsByTitle(%q) = %v, %v; want %v", title, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package queryparams
+
+import (
+	"cmp"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// ListQuery is the parsed form of the common list parameters:
+// ?limit=&offset=&sort=[-]field plus any boolean filters.
+type ListQuery struct {
+	Limit   int
+	Offset  int
+	Sort    string
+	Desc    bool
+	Filters map[string]bool
+
+	// TieBreaker is a unique column sorted ascending after Sort, so rows
+	// with equal sort values keep the same order from page to page.
+	TieBreaker string
+}
+
+// Options describes what a list endpoint accepts.
+type Options struct {
+	SortFields []string
+	// DefaultSort applies when ?sort is absent. Like the parameter, a
+	// leading "-" means descending, e.g. "-created_at".
+	DefaultSort string
+	// TieBreaker must name a unique column. Empty means "id".
+	TieBreaker  string
+	BoolFilters []string
+
+	// DefaultLimit is the page size when ?limit is absent. Zero means the
+	// package default DefaultLimit.
+	DefaultLimit int
+	// MaxLimit caps the page size; larger requests are clamped to it.
+	// Zero means the package default MaxLimit.
+	MaxLimit int
+}
+
+// Error holds one message per invalid parameter.
+type Error struct {
+	Fields map[string]string
+}
+
+func (e *Error) Error() string {
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+": "+e.Fields[k])
+	}
+	return "invalid query parameters: " + strings.Join(parts, ", ")
+}
+
+// Parse reads list parameters from the query string, applying defaults and
+// bounds. All problems are collected into a single *Error.
+func Parse(values url.Values, opts Options) (ListQuery, error) {
+	q := ListQuery{
+		Limit:      cmp.Or(opts.DefaultLimit, DefaultLimit),
+		Sort:       strings.TrimPrefix(opts.DefaultSort, "-"),
+		Desc:       strings.HasPrefix(opts.DefaultSort, "-"),
+		Filters:    map[string]bool{},
+		TieBreaker: cmp.Or(opts.TieBreaker, "id"),
+	}
+	fields := map[string]string{}
+
+	maxLimit := opts.MaxLimit
+	if maxLimit <= 0 {
+		maxLimit = MaxLimit
+	}
+	q.Limit = min(q.Limit, maxLimit)
+
+	if v := values.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		switch {
+		case err != nil:
+			fields["limit"] = "must be an integer"
+		case n < 1:
+			fields["limit"] = "must be at least 1"
+		default:
+			// Clamp rather than fail so naive clients still get a page.
+			q.Limit = min(n, maxLimit)
+		}
+	}
+
+	if v := values.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		switch {
+		case err != nil:
+			fields["offset"] = "must be an integer"
+		case n < 0:
+			fields["offset"] = "must not be negative"
+		default:
+			q.Offset = n
+		}
+	}
+
+	if v := values.Get("sort"); v != "" {
+		name := strings.TrimPrefix(v, "-")
+		if slices.Contains(opts.SortFields, name) {
+			q.Sort = name
+			q.Desc = strings.HasPrefix(v, "-")
+		} else {
+			fields["sort"] = "must be one of " + strings.Join(opts.SortFields, ", ")
+		}
+	}
+
+	for _, name := range opts.BoolFilters {
+		v := values.Get(name)
+		if v == "" {
+			continue
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			fields[name] = "must be true or false"
+			continue
+		}
+		q.Filters[name] = b
+	}
+
+	if len(fields) > 0 {
+		return q, &Error{Fields: fields}
+	}
+	return q, nil
+}
+
+// ParseFields reads ?fields=a,b and checks every name against allowed.
+// An absent parameter returns nil, meaning "all fields".
+func ParseFields(values url.Values, allowed []string) ([]string, error) {
+	v := values.Get("fields")
+	if v == "" {
+		return nil, nil
+	}
+
+	var fields, unknown []string
+	for _, name := range strings.Split(v, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !slices.Contains(allowed, name) {
+			unknown = append(unknown, name)
+			continue
+		}
+		fields = append(fields, name)
+	}
+
+	if len(unknown) > 0 {
+		return nil, &Error{Fields: map[string]string{
+			"fields": fmt.Sprintf("unknown field(s) %s; allowed: %s",
+				strings.Join(unknown, ", "), strings.Join(allowed, ", ")),
+		}}
+	}
+	if len(fields) == 0 {
+		return nil, &Error{Fields: map[string]string{"fields": "must name at least one field"}}
+	}
+	return fields, nil
+}
+
+// ParseIDs reads ?ids=1,2,3, keeping request order and dropping repeats.
+// An absent parameter returns nil. At most maxIDs ids are accepted; zero
+// means the package default MaxLimit.
+func ParseIDs(values url.Values, maxIDs int) ([]int64, error) {
+	v := values.Get("ids")
+	if v == "" {
+		return nil, nil
+	}
+
+	if maxIDs <= 0 {
+		maxIDs = MaxLimit
+	}
+	parts := strings.Split(v, ",")
+	if len(parts) > maxIDs {
+		return nil, &Error{Fields: map[string]string{"ids": fmt.Sprintf("at most %d ids per request", maxIDs)}}
+	}
+
+	ids := make([]int64, 0, len(parts))
+	seen := make(map[int64]bool, len(parts))
+	for _, p := range parts {
+		p = strings.TrimSpace(p)
+		id, err := strconv.ParseInt(p, 10, 64)
+		if err != nil || id < 1 {
+			return nil, &Error{Fields: map[string]string{"ids": fmt.Sprintf("invalid id %q", p)}}
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
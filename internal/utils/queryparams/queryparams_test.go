@@ -0,0 +1,70 @@
+package queryparams
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+var todoOptions = Options{
+	SortFields:   []string{"id", "title", "created_at"},
+	DefaultSort:  "id",
+	BoolFilters:  []string{"done"},
+	DefaultLimit: 20,
+	MaxLimit:     100,
+}
+
+func parse(t *testing.T, query string, opts Options) (ListQuery, error) {
+	t.Helper()
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Parse(values, opts)
+}
+
+func TestParseClampsHugeLimit(t *testing.T) {
+	q, err := parse(t, "limit=1000000", todoOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Limit != 100 {
+		t.Errorf("limit = %d, want it clamped to 100", q.Limit)
+	}
+}
+
+func TestParseClampsToPackageMaxWhenUnset(t *testing.T) {
+	q, err := parse(t, "limit=1000000", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Limit != MaxLimit {
+		t.Errorf("limit = %d, want %d", q.Limit, MaxLimit)
+	}
+}
+
+func TestParseRejectsBadValues(t *testing.T) {
+	_, err := parse(t, "limit=0&offset=-1&sort=secret&done=maybe", todoOptions)
+	var qErr *Error
+	if !errors.As(err, &qErr) {
+		t.Fatalf("err = %v, want *Error", err)
+	}
+	for _, name := range []string{"limit", "offset", "sort", "done"} {
+		if qErr.Fields[name] == "" {
+			t.Errorf("no message for %s in %v", name, qErr.Fields)
+		}
+	}
+}
+
+func TestParseSortAndFilters(t *testing.T) {
+	q, err := parse(t, "sort=-created_at&done=true&offset=40", todoOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Sort != "created_at" || !q.Desc || q.Offset != 40 || q.TieBreaker != "id" {
+		t.Errorf("query = %+v", q)
+	}
+	if done, ok := q.Filters["done"]; !ok || !done {
+		t.Errorf("filters = %v, want done=true", q.Filters)
+	}
+}
@@ -0,0 +1,133 @@
+package response
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Body formats render can produce.
+type format int
+
+const (
+	formatJSON format = iota
+	formatJSONAPI
+	formatXML
+)
+
+func (f format) String() string {
+	switch f {
+	case formatJSONAPI:
+		return "jsonapi"
+	case formatXML:
+		return "xml"
+	}
+	return "json"
+}
+
+// Representation names the body format render will use for the request:
+// "json", "jsonapi" or "xml". Validators such as ETags must include it, since
+// one URL has a different body per format.
+func Representation(c echo.Context) string {
+	return negotiate(c).String()
+}
+
+// varyOn adds header to the response's Vary list unless it is there
+// already.
+func varyOn(c echo.Context, header string) {
+	h := c.Response().Header()
+	for _, v := range h.Values(echo.HeaderVary) {
+		for _, name := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(name), header) {
+				return
+			}
+		}
+	}
+	h.Add(echo.HeaderVary, header)
+}
+
+// mediaRange is one entry of an Accept header, e.g. application/*;q=0.8.
+type mediaRange struct {
+	typ, subtype string
+	q            float64
+}
+
+// parseAccept splits an Accept header into its media ranges. Entries that
+// don't parse are skipped, and a missing or invalid q counts as 1.
+func parseAccept(header string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		typ, subtype, ok := strings.Cut(strings.ToLower(strings.TrimSpace(params[0])), "/")
+		if !ok || typ == "" || subtype == "" {
+			continue
+		}
+		r := mediaRange{typ: typ, subtype: subtype, q: 1}
+		for _, p := range params[1:] {
+			k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+			if strings.EqualFold(k, "q") {
+				if q, err := strconv.ParseFloat(v, 64); err == nil && q >= 0 && q <= 1 {
+					r.q = q
+				}
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// quality returns the q the client gives mime, taken from the most specific
+// range that matches it (type/subtype, then type/*, then */*), or 0 when
+// none does. A request without an Accept header accepts everything.
+func quality(ranges []mediaRange, mime string) float64 {
+	if ranges == nil {
+		return 1
+	}
+	typ, subtype, _ := strings.Cut(mime, "/")
+	q, specificity := 0.0, -1
+	for _, r := range ranges {
+		var s int
+		switch {
+		case r.typ == typ && r.subtype == subtype:
+			s = 2
+		case r.typ == typ && r.subtype == "*":
+			s = 1
+		case r.typ == "*" && r.subtype == "*":
+			s = 0
+		default:
+			continue
+		}
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+	return q
+}
+
+// negotiate picks the body format for the request's Accept header. JSON is
+// the default and wins whenever the client accepts it at all, including
+// through a wildcard, so browsers (which rank application/xml above */*)
+// still get JSON. Otherwise it is whichever alternative the client ranks
+// highest, and JSON again if it accepts none of them.
+func negotiate(c echo.Context) format {
+	ranges := parseAccept(c.Request().Header.Get(echo.HeaderAccept))
+	if quality(ranges, echo.MIMEApplicationJSON) > 0 {
+		return formatJSON
+	}
+
+	best, bestQ := formatJSON, 0.0
+	for _, alt := range []struct {
+		f    format
+		mime string
+	}{
+		{formatJSONAPI, MIMEApplicationJSONAPI},
+		{formatXML, echo.MIMEApplicationXML},
+		{formatXML, echo.MIMETextXML},
+	} {
+		if q := quality(ranges, alt.mime); q > bestQ {
+			best, bestQ = alt.f, q
+		}
+	}
+	return best
+}
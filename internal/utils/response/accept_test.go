@@ -0,0 +1,52 @@
+package response
+
+import "testing"
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   format
+	}{
+		{"", formatJSON},
+		{"*/*", formatJSON},
+		{"application/json", formatJSON},
+		{"application/xml", formatXML},
+		{"text/xml", formatXML},
+		{MIMEApplicationJSONAPI, formatJSONAPI},
+		// Browsers rank XML above */*, but JSON is still acceptable.
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", formatJSON},
+		{"application/json, application/xml;q=0.1", formatJSON},
+		{"application/xml, application/json;q=0.5", formatJSON},
+		{"application/json;q=0, application/xml", formatXML},
+		{"application/xml;q=0.4, application/vnd.api+json;q=0.6", formatJSONAPI},
+		{"APPLICATION/XML", formatXML},
+		// Nothing we offer is acceptable: answer JSON rather than 406.
+		{"image/png", formatJSON},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			c, _ := newContext(tt.accept)
+			if got := negotiate(c); got != tt.want {
+				t.Errorf("negotiate(%q) = %v, want %v", tt.accept, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuality(t *testing.T) {
+	ranges := parseAccept("text/*;q=0.3, text/html;q=0.7, */*;q=0.1, application/json;q=abc")
+	tests := []struct {
+		mime string
+		want float64
+	}{
+		{"text/html", 0.7},
+		{"text/plain", 0.3},
+		{"image/png", 0.1},
+		{"application/json", 1}, // an invalid q counts as 1
+	}
+	for _, tt := range tests {
+		if got := quality(ranges, tt.mime); got != tt.want {
+			t.Errorf("quality(%q) = %v, want %v", tt.mime, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package response
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SetCacheHeaders writes Cache-Control, Last-Modified and ETag, then reports
+// whether the client's copy is still fresh (If-None-Match / If-Modified-Since),
+// in which case the caller should answer with NotModified. etag must differ
+// per Representation. Vary: Accept is set here too, since a 304 skips
+// render.
+func SetCacheHeaders(c echo.Context, maxAge time.Duration, lastModified time.Time, etag string) bool {
+	varyOn(c, echo.HeaderAccept)
+	h := c.Response().Header()
+	h.Set("Cache-Control", fmt.Sprintf("max-age=%d", int(maxAge.Seconds())))
+	h.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	h.Set("ETag", etag)
+
+	req := c.Request()
+	// If-None-Match wins over If-Modified-Since (RFC 9110 13.2.2).
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			if strings.TrimSpace(tag) == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ims := req.Header.Get("If-Modified-Since"); ims != "" {
+		since, err := http.ParseTime(ims)
+		if err != nil {
+			return false
+		}
+		// HTTP dates have one-second precision.
+		return !lastModified.Truncate(time.Second).After(since)
+	}
+	return false
+}
+
+func NotModified(c echo.Context) error {
+	return c.NoContent(http.StatusNotModified)
+}
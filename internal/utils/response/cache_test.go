@@ -0,0 +1,66 @@
+package response
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestSetCacheHeaders(t *testing.T) {
+	modified := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		fresh  bool
+	}{
+		{"no validators", nil, false},
+		{"matching ETag", map[string]string{"If-None-Match": `W/"a", W/"1-json"`}, true},
+		{"other ETag", map[string]string{"If-None-Match": `W/"1-xml"`}, false},
+		{"not modified since", map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, true},
+		{"modified since", map[string]string{"If-Modified-Since": modified.Add(-time.Hour).Format(http.TimeFormat)}, false},
+		// If-None-Match wins over If-Modified-Since.
+		{"ETag mismatch wins", map[string]string{
+			"If-None-Match":     `W/"1-xml"`,
+			"If-Modified-Since": modified.Format(http.TimeFormat),
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, rec := newContext("")
+			for k, v := range tt.header {
+				c.Request().Header.Set(k, v)
+			}
+			if got := SetCacheHeaders(c, 30*time.Second, modified, `W/"1-json"`); got != tt.fresh {
+				t.Errorf("fresh = %v, want %v", got, tt.fresh)
+			}
+			h := rec.Header()
+			if h.Get("Cache-Control") != "max-age=30" || h.Get("ETag") != `W/"1-json"` || h.Get(echo.HeaderVary) != echo.HeaderAccept {
+				t.Errorf("headers = %v", h)
+			}
+		})
+	}
+}
+
+func TestRepresentationAndVary(t *testing.T) {
+	for accept, want := range map[string]string{
+		"":                      "json",
+		echo.MIMEApplicationXML: "xml",
+		MIMEApplicationJSONAPI:  "jsonapi",
+	} {
+		c, rec := newContext(accept)
+		if got := Representation(c); got != want {
+			t.Errorf("Representation(%q) = %q, want %q", accept, got, want)
+		}
+
+		c.Response().Header().Add(echo.HeaderVary, "X-Tenant-ID")
+		if err := OK(c, map[string]string{"status": "ok"}); err != nil {
+			t.Fatal(err)
+		}
+		if got := rec.Header().Values(echo.HeaderVary); len(got) != 2 || got[1] != echo.HeaderAccept {
+			t.Errorf("Vary = %v, want X-Tenant-ID and Accept", got)
+		}
+	}
+}
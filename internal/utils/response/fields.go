@@ -0,0 +1,43 @@
+package response
+
+import "encoding/json"
+
+// SelectFields trims data down to the given JSON keys. data may be a single
+// object or a slice of objects; nil fields returns data unchanged.
+func SelectFields(data any, fields []string) (any, error) {
+	if fields == nil {
+		return data, nil
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []map[string]any
+	if err := json.Unmarshal(raw, &list); err == nil {
+		for _, item := range list {
+			keepOnly(item, fields)
+		}
+		return list, nil
+	}
+
+	var item map[string]any
+	if err := json.Unmarshal(raw, &item); err != nil {
+		return nil, err
+	}
+	keepOnly(item, fields)
+	return item, nil
+}
+
+func keepOnly(item map[string]any, fields []string) {
+	keep := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		keep[f] = true
+	}
+	for k := range item {
+		if !keep[k] {
+			delete(item, k)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package response
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// errorPage is the minimal page shown to browsers. html/template escapes
+// the message, which may echo parts of the request.
+var errorPage = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8"><title>{{.Code}} {{.Status}}</title></head>
+<body>
+<h1>{{.Code}} {{.Status}}</h1>
+<p>{{.Message}}</p>
+{{- if .Fields}}
+<ul>
+{{- range $field, $msg := .Fields}}
+<li><code>{{$field}}</code> {{$msg}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .RequestID}}
+<p><small>Request ID: {{.RequestID}}</small></p>
+{{- end}}
+</body>
+</html>
+`))
+
+// wantsHTML reports whether the client ranks an HTML page above JSON, as
+// browsers do (their text/html outranks */*). API clients, and requests
+// without an Accept header, get JSON.
+func wantsHTML(c echo.Context) bool {
+	ranges := parseAccept(c.Request().Header.Get(echo.HeaderAccept))
+	return quality(ranges, echo.MIMETextHTML) > quality(ranges, echo.MIMEApplicationJSON)
+}
+
+// htmlError renders an error body built by this package, such as
+// {"error": ..., "fields": ...}, as a page with the same status, message,
+// invalid fields and request ID.
+func htmlError(c echo.Context, code int, data any) error {
+	page := map[string]any{
+		"Code":    code,
+		"Status":  http.StatusText(code),
+		"Message": http.StatusText(code),
+	}
+	switch d := data.(type) {
+	case map[string]string:
+		page["Message"], page["RequestID"] = d["error"], d["request_id"]
+	case map[string]any:
+		if msg, ok := d["error"]; ok {
+			page["Message"] = fmt.Sprint(msg)
+		}
+		page["RequestID"] = d["request_id"]
+		page["Fields"] = d["fields"]
+	}
+
+	var buf bytes.Buffer
+	if err := errorPage.Execute(&buf, page); err != nil {
+		return err
+	}
+	return c.HTMLBlob(code, buf.Bytes())
+}
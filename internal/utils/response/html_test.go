@@ -0,0 +1,126 @@
+package response
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/manish-npx/simple-go-echo/internal/errs"
+	"github.com/manish-npx/simple-go-echo/internal/validation"
+)
+
+const browserAccept = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+
+func TestErrorsNegotiateHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		send func(c echo.Context) error
+		code int
+		want []string // in the page; the JSON body has them too
+	}{
+		{
+			name: "handler 404",
+			send: func(c echo.Context) error { return FromError(c, errs.NotFound("Todo not found")) },
+			code: http.StatusNotFound,
+			want: []string{"Todo not found"},
+		},
+		{
+			name: "validation",
+			send: func(c echo.Context) error {
+				return FromError(c, &validation.Errors{Fields: map[string]string{"title": "is required"}})
+			},
+			code: http.StatusBadRequest,
+			want: []string{"Validation failed", "title", "is required"},
+		},
+		{
+			name: "unmatched route",
+			send: func(c echo.Context) error { CustomErrorHandler(echo.ErrNotFound, c); return nil },
+			code: http.StatusNotFound,
+			want: []string{"route not found"},
+		},
+		{
+			name: "framework error",
+			send: func(c echo.Context) error { CustomErrorHandler(echo.ErrMethodNotAllowed, c); return nil },
+			code: http.StatusMethodNotAllowed,
+			want: []string{"Method Not Allowed"},
+		},
+		{
+			name: "internal",
+			send: func(c echo.Context) error {
+				c.Response().Header().Set(echo.HeaderXRequestID, "req-1")
+				return InternalServerError(c, errors.New("connection refused"))
+			},
+			code: http.StatusInternalServerError,
+			want: []string{"internal error", "req-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+" browser", func(t *testing.T) {
+			c, rec := newContext(browserAccept)
+			if err := tt.send(c); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != tt.code {
+				t.Errorf("status = %d, want %d", rec.Code, tt.code)
+			}
+			if ct := rec.Header().Get(echo.HeaderContentType); !strings.HasPrefix(ct, echo.MIMETextHTML) {
+				t.Errorf("Content-Type = %q, want HTML", ct)
+			}
+			body := rec.Body.String()
+			for _, s := range append(tt.want, "<!DOCTYPE html>") {
+				if !strings.Contains(body, s) {
+					t.Errorf("page lacks %q:\n%s", s, body)
+				}
+			}
+			if strings.Contains(body, "connection refused") {
+				t.Errorf("page leaks the internal error:\n%s", body)
+			}
+		})
+		t.Run(tt.name+" JSON", func(t *testing.T) {
+			c, rec := newContext(echo.MIMEApplicationJSON)
+			if err := tt.send(c); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != tt.code {
+				t.Errorf("status = %d, want %d", rec.Code, tt.code)
+			}
+			if ct := rec.Header().Get(echo.HeaderContentType); !strings.HasPrefix(ct, echo.MIMEApplicationJSON) {
+				t.Errorf("Content-Type = %q, want JSON", ct)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(rec.Body.String(), s) {
+					t.Errorf("body lacks %q: %s", s, rec.Body)
+				}
+			}
+		})
+	}
+}
+
+func TestBrowsersGetJSONOnSuccess(t *testing.T) {
+	c, rec := newContext(browserAccept)
+	if err := OK(c, map[string]string{"status": "ok"}); err != nil {
+		t.Fatal(err)
+	}
+	if ct := rec.Header().Get(echo.HeaderContentType); !strings.HasPrefix(ct, echo.MIMEApplicationJSON) {
+		t.Errorf("Content-Type = %q, want JSON", ct)
+	}
+}
+
+func TestWantsHTML(t *testing.T) {
+	for accept, want := range map[string]bool{
+		"":                            false,
+		"*/*":                         false,
+		echo.MIMEApplicationJSON:      false,
+		"text/html":                   true,
+		browserAccept:                 true,
+		"text/html, application/json": false,
+		"text/html;q=0.5, application/json;q=0.9": false,
+	} {
+		c, _ := newContext(accept)
+		if got := wantsHTML(c); got != want {
+			t.Errorf("wantsHTML(%q) = %v, want %v", accept, got, want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+
+	"github.com/labstack/echo/v4"
+)
+
+const MIMEApplicationJSONAPI = "application/vnd.api+json"
+
+// Resource is implemented by models that can be rendered as JSON:API
+// resource objects.
+type Resource interface {
+	ResourceType() string
+	ResourceID() string
+}
+
+type resourceObject struct {
+	Type       string         `json:"type"`
+	ID         string         `json:"id"`
+	Attributes map[string]any `json:"attributes"`
+}
+
+type document struct {
+	Data any `json:"data"`
+}
+
+// toDocument converts a Resource or a slice of Resources into a JSON:API
+// document. ok is false when data has no JSON:API representation.
+func toDocument(data any) (doc document, ok bool, err error) {
+	if r, isResource := data.(Resource); isResource {
+		obj, err := toResourceObject(r)
+		return document{Data: obj}, true, err
+	}
+
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Slice {
+		return document{}, false, nil
+	}
+
+	objects := make([]resourceObject, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		r, isResource := v.Index(i).Interface().(Resource)
+		if !isResource {
+			return document{}, false, nil
+		}
+		obj, err := toResourceObject(r)
+		if err != nil {
+			return document{}, false, err
+		}
+		objects = append(objects, obj)
+	}
+	return document{Data: objects}, true, nil
+}
+
+func toResourceObject(r Resource) (resourceObject, error) {
+	raw, err := json.Marshal(r)
+	if err != nil {
+		return resourceObject{}, err
+	}
+
+	var attrs map[string]any
+	if err := json.Unmarshal(raw, &attrs); err != nil {
+		return resourceObject{}, err
+	}
+	delete(attrs, "id")
+
+	return resourceObject{
+		Type:       r.ResourceType(),
+		ID:         r.ResourceID(),
+		Attributes: attrs,
+	}, nil
+}
+
+// render negotiates the response format from the Accept header (see
+// negotiate): JSON:API when asked for and supported by data, XML for XML
+// clients, and plain JSON otherwise. Errors are a page for browsers (see
+// wantsHTML). Responses carry Vary: Accept so caches keep the formats
+// apart.
+func render(c echo.Context, code int, data any) error {
+	if ClientGone(c) {
+		// Nobody is listening; record the status for the access log only.
+		c.Response().Status = StatusClientClosedRequest
+		return nil
+	}
+	varyOn(c, echo.HeaderAccept)
+	if code >= http.StatusBadRequest && wantsHTML(c) {
+		return htmlError(c, code, data)
+	}
+	switch negotiate(c) {
+	case formatXML:
+		return c.XML(code, toXML(data, xmlRootName(data)))
+	case formatJSONAPI:
+		doc, ok, err := toDocument(data)
+		if err != nil {
+			return err
+		}
+		if ok {
+			// Through the serializer, so json_naming applies to the
+			// attributes too.
+			c.Response().Header().Set(echo.HeaderContentType, MIMEApplicationJSONAPI)
+			c.Response().WriteHeader(code)
+			return c.Echo().JSONSerializer.Serialize(c, doc, "")
+		}
+	}
+	return c.JSON(code, data)
+}
@@ -0,0 +1,107 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/manish-npx/simple-go-echo/internal/models"
+)
+
+// newContext returns a context for a GET with the given Accept header, and
+// the recorder its response goes to.
+func newContext(accept string) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodGet, "/api/todos/1", nil)
+	if accept != "" {
+		req.Header.Set(echo.HeaderAccept, accept)
+	}
+	rec := httptest.NewRecorder()
+	return echo.New().NewContext(req, rec), rec
+}
+
+func TestRenderJSONAPI(t *testing.T) {
+	todo := models.Todo{ID: 1, Title: "Write tests"}
+
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		check       func(t *testing.T, body map[string]any)
+	}{
+		{
+			name:        "JSON:API",
+			accept:      MIMEApplicationJSONAPI,
+			contentType: MIMEApplicationJSONAPI,
+			check: func(t *testing.T, body map[string]any) {
+				data, _ := body["data"].(map[string]any)
+				if data["type"] != "todos" || data["id"] != "1" {
+					t.Errorf("data = %v, want type todos and id \"1\"", data)
+				}
+				attrs, _ := data["attributes"].(map[string]any)
+				if attrs["title"] != "Write tests" {
+					t.Errorf("attributes = %v, want the title", attrs)
+				}
+				if _, ok := attrs["id"]; ok {
+					t.Error("attributes repeat the id")
+				}
+			},
+		},
+		{
+			name:        "plain JSON",
+			accept:      echo.MIMEApplicationJSON,
+			contentType: echo.MIMEApplicationJSON,
+			check: func(t *testing.T, body map[string]any) {
+				if body["id"] != float64(1) || body["title"] != "Write tests" {
+					t.Errorf("body = %v, want the plain todo", body)
+				}
+				if _, ok := body["data"]; ok {
+					t.Error("plain JSON is wrapped in a document")
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, rec := newContext(tt.accept)
+			if err := OK(c, todo); err != nil {
+				t.Fatal(err)
+			}
+			if got := rec.Header().Get(echo.HeaderContentType); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			var body map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, body)
+		})
+	}
+}
+
+func TestRenderJSONAPIList(t *testing.T) {
+	c, rec := newContext(MIMEApplicationJSONAPI)
+	if err := OK(c, []models.Todo{{ID: 1}, {ID: 2}}); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Data []resourceObject `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Data) != 2 || doc.Data[1].ID != "2" {
+		t.Errorf("data = %+v, want two resource objects", doc.Data)
+	}
+}
+
+func TestRenderJSONAPIFallsBackForNonResources(t *testing.T) {
+	c, rec := newContext(MIMEApplicationJSONAPI)
+	if err := OK(c, map[string]int{"count": 3}); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.Header().Get(echo.HeaderContentType); got != echo.MIMEApplicationJSON {
+		t.Errorf("Content-Type = %q, want plain JSON", got)
+	}
+}
@@ -0,0 +1,48 @@
+package response
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// paginationLinks builds an RFC 8288 Link header value with first, prev,
+// next and last pages. Each link repeats the current request's query
+// string, so filters and sort order carry over.
+func paginationLinks(c echo.Context, limit, offset, total int) string {
+	if limit <= 0 {
+		return ""
+	}
+
+	last := 0
+	if total > 0 {
+		last = (total - 1) / limit * limit
+	}
+
+	links := []string{pageLink(c, limit, 0, "first")}
+	if offset > 0 {
+		links = append(links, pageLink(c, limit, max(offset-limit, 0), "prev"))
+	}
+	if offset+limit < total {
+		links = append(links, pageLink(c, limit, offset+limit, "next"))
+	}
+	links = append(links, pageLink(c, limit, last, "last"))
+
+	return strings.Join(links, ", ")
+}
+
+func pageLink(c echo.Context, limit, offset int, rel string) string {
+	req := c.Request()
+	u := *req.URL
+	u.Scheme = c.Scheme()
+	u.Host = req.Host
+
+	q := u.Query()
+	q.Set("limit", strconv.Itoa(limit))
+	q.Set("offset", strconv.Itoa(offset))
+	u.RawQuery = q.Encode()
+
+	return fmt.Sprintf(`<%s>; rel="%s"`, u.String(), rel)
+}
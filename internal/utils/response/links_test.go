@@ -0,0 +1,60 @@
+package response
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func links(t *testing.T, target string, limit, offset, total int) string {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	SetPagination(c, limit, offset, total)
+	return rec.Header().Get("Link")
+}
+
+func TestPaginationLinks(t *testing.T) {
+	got := links(t, "/api/todos?done=false&limit=10&offset=10", 10, 10, 35)
+	for _, want := range []string{
+		`<http://example.com/api/todos?done=false&limit=10&offset=0>; rel="first"`,
+		`<http://example.com/api/todos?done=false&limit=10&offset=0>; rel="prev"`,
+		`<http://example.com/api/todos?done=false&limit=10&offset=20>; rel="next"`,
+		`<http://example.com/api/todos?done=false&limit=10&offset=30>; rel="last"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Link = %s\nmissing %s", got, want)
+		}
+	}
+}
+
+func TestPaginationLinksLastPageHasNoNext(t *testing.T) {
+	got := links(t, "/api/todos?limit=10&offset=30", 10, 30, 35)
+	if strings.Contains(got, `rel="next"`) {
+		t.Errorf("Link = %s, want no next on the last page", got)
+	}
+	if !strings.Contains(got, `offset=30>; rel="last"`) {
+		t.Errorf("Link = %s, want last at offset 30", got)
+	}
+}
+
+func TestPaginationLinksFirstPageHasNoPrev(t *testing.T) {
+	got := links(t, "/api/todos", 10, 0, 35)
+	if strings.Contains(got, `rel="prev"`) {
+		t.Errorf("Link = %s, want no prev on the first page", got)
+	}
+}
+
+func TestSetPaginationHeaders(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/todos", nil)
+	rec := httptest.NewRecorder()
+	SetPagination(echo.New().NewContext(req, rec), 20, 40, 0)
+	h := rec.Header()
+	if h.Get("X-Pagination-Limit") != "20" || h.Get("X-Pagination-Offset") != "40" || h.Get("X-Total-Count") != "0" {
+		t.Errorf("headers = %v", h)
+	}
+}
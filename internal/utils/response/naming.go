@@ -0,0 +1,63 @@
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CamelCaseSerializer is an echo.JSONSerializer that renames every object
+// key in responses from snake_case to camelCase (created_at -> createdAt).
+// Request bodies are decoded unchanged.
+type CamelCaseSerializer struct {
+	echo.DefaultJSONSerializer
+}
+
+func (s CamelCaseSerializer) Serialize(c echo.Context, i any, indent string) error {
+	raw, err := json.Marshal(i)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber() // keep int64 IDs exact
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(c.Response())
+	if indent != "" {
+		enc.SetIndent("", indent)
+	}
+	return enc.Encode(camelKeys(v))
+}
+
+func camelKeys(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			out[camelCase(k)] = camelKeys(val)
+		}
+		return out
+	case []any:
+		for i, val := range v {
+			v[i] = camelKeys(val)
+		}
+		return v
+	}
+	return v
+}
+
+func camelCase(s string) string {
+	parts := strings.Split(s, "_")
+	for i := 1; i < len(parts); i++ {
+		if p := parts[i]; p != "" {
+			parts[i] = strings.ToUpper(p[:1]) + p[1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
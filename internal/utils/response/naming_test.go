@@ -0,0 +1,98 @@
+package response
+
+import (
+	"cmp"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/manish-npx/simple-go-echo/internal/models"
+)
+
+func TestJSONNaming(t *testing.T) {
+	todos := []models.Todo{{ID: 9007199254740993, Title: "Big id", CreatedAt: time.Unix(0, 0).UTC()}}
+
+	tests := []struct {
+		name       string
+		serializer echo.JSONSerializer
+		want       []string
+		notWant    []string
+	}{
+		{
+			name:       "snake_case",
+			serializer: echo.DefaultJSONSerializer{},
+			want:       []string{"created_at", "updated_at", "external_id", "public_id"},
+			notWant:    []string{"createdAt"},
+		},
+		{
+			name:       "camelCase",
+			serializer: CamelCaseSerializer{},
+			want:       []string{"createdAt", "updatedAt", "externalId", "publicId"},
+			notWant:    []string{"created_at"},
+		},
+	}
+	for _, tt := range tests {
+		for _, accept := range []string{"", MIMEApplicationJSONAPI} {
+			t.Run(tt.name+" "+cmp.Or(accept, "JSON"), func(t *testing.T) {
+				c, rec := newContext(accept)
+				c.Echo().JSONSerializer = tt.serializer
+				if err := OK(c, todos); err != nil {
+					t.Fatal(err)
+				}
+
+				todo, id := decodeTodo(t, rec.Body.Bytes(), accept != "")
+				for _, k := range tt.want {
+					if _, ok := todo[k]; !ok {
+						t.Errorf("missing key %q in %s", k, rec.Body)
+					}
+				}
+				for _, k := range tt.notWant {
+					if _, ok := todo[k]; ok {
+						t.Errorf("unexpected key %q in %s", k, rec.Body)
+					}
+				}
+				if id != "9007199254740993" {
+					t.Errorf("id = %s, want it exact", id)
+				}
+			})
+		}
+	}
+}
+
+// decodeTodo returns the fields of the only todo in body, a plain array or
+// a JSON:API document, and its id.
+func decodeTodo(t *testing.T, body []byte, jsonAPI bool) (map[string]json.RawMessage, string) {
+	t.Helper()
+	if !jsonAPI {
+		var todos []map[string]json.RawMessage
+		if err := json.Unmarshal(body, &todos); err != nil || len(todos) != 1 {
+			t.Fatalf("body = %s, want a one-todo array (%v)", body, err)
+		}
+		return todos[0], string(todos[0]["id"])
+	}
+
+	var doc struct {
+		Data []struct {
+			ID         string                     `json:"id"`
+			Attributes map[string]json.RawMessage `json:"attributes"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil || len(doc.Data) != 1 {
+		t.Fatalf("body = %s, want a one-todo document (%v)", body, err)
+	}
+	return doc.Data[0].Attributes, doc.Data[0].ID
+}
+
+func TestCamelCase(t *testing.T) {
+	for in, want := range map[string]string{
+		"id":            "id",
+		"created_at":    "createdAt",
+		"x_total_count": "xTotalCount",
+		"trailing_":     "trailing",
+	} {
+		if got := camelCase(in); got != want {
+			t.Errorf("camelCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
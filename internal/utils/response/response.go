@@ -1,48 +1,276 @@
 package response
 
 import (
+	"context"
+	"errors"
+	"maps"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/manish-npx/simple-go-echo/internal/database"
+	"github.com/manish-npx/simple-go-echo/internal/errs"
+	"github.com/manish-npx/simple-go-echo/internal/logger"
+	"github.com/manish-npx/simple-go-echo/internal/validation"
 )
 
+// StatusClientClosedRequest is the nginx convention for a request the
+// client abandoned before we could answer. It is only ever logged.
+const StatusClientClosedRequest = 499
+
+// connectionLostRetryAfter is how long clients are asked to wait after a
+// lost database connection; a restarting Postgres is usually back by then.
+const connectionLostRetryAfter = 5 * time.Second
+
 func OK(c echo.Context, data any) error {
-	return c.JSON(http.StatusOK, data)
+	return render(c, http.StatusOK, data)
 }
 
 func Created(c echo.Context, data any) error {
-	return c.JSON(http.StatusCreated, data)
+	return render(c, http.StatusCreated, data)
+}
+
+// WithStatus renders data with an arbitrary status code, for responses the
+// named helpers don't cover (e.g. a 503 readiness body).
+func WithStatus(c echo.Context, code int, data any) error {
+	return render(c, code, data)
 }
 
 func NoContent(c echo.Context) error {
+	if ClientGone(c) {
+		c.Response().Status = StatusClientClosedRequest
+		return nil
+	}
 	return c.NoContent(http.StatusNoContent)
 }
 
 func BadRequest(c echo.Context, msg string) error {
-	return c.JSON(http.StatusBadRequest, map[string]string{"error": msg})
+	return render(c, http.StatusBadRequest, map[string]string{"error": msg})
+}
+
+// ValidationError reports one message per invalid field.
+func ValidationError(c echo.Context, msg string, fields map[string]string) error {
+	return render(c, http.StatusBadRequest, map[string]any{
+		"error":  msg,
+		"fields": fields,
+	})
 }
 
 func NotFound(c echo.Context, msg string) error {
-	return c.JSON(http.StatusNotFound, map[string]string{"error": msg})
+	return render(c, http.StatusNotFound, map[string]string{"error": msg})
+}
+
+func Forbidden(c echo.Context, msg string) error {
+	return render(c, http.StatusForbidden, map[string]string{"error": msg})
+}
+
+func Conflict(c echo.Context, msg string) error {
+	return render(c, http.StatusConflict, map[string]string{"error": msg})
+}
+
+func PayloadTooLarge(c echo.Context, msg string) error {
+	return render(c, http.StatusRequestEntityTooLarge, map[string]string{"error": msg})
+}
+
+// FromError renders err according to its kind (see package errs): 404,
+// 409, 400, 403, 401 or 412 with the error's client-safe message, and a 400 listing
+// each field for validation.Errors. Anything else is a 500 whose detail is
+// only logged.
+func FromError(c echo.Context, err error) error {
+	return fromError(c, err, nil)
+}
+
+// FromErrorAt is FromError for one item of a batch: client errors also
+// carry "index", the position of the item that failed.
+func FromErrorAt(c echo.Context, err error, index int) error {
+	return fromError(c, err, map[string]any{"index": index})
+}
+
+func fromError(c echo.Context, err error, extra map[string]any) error {
+	var valErr *validation.Errors
+	if errors.As(err, &valErr) {
+		body := map[string]any{"error": "Validation failed", "fields": valErr.Fields}
+		maps.Copy(body, extra)
+		return render(c, http.StatusBadRequest, body)
+	}
+
+	var code int
+	switch {
+	case errors.Is(err, errs.ErrNotFound):
+		code = http.StatusNotFound
+	case errors.Is(err, errs.ErrConflict):
+		code = http.StatusConflict
+	case errors.Is(err, errs.ErrValidation):
+		code = http.StatusBadRequest
+	case errors.Is(err, errs.ErrForbidden):
+		code = http.StatusForbidden
+	case errors.Is(err, errs.ErrUnauthorized):
+		code = http.StatusUnauthorized
+	case errors.Is(err, errs.ErrPrecondition):
+		code = http.StatusPreconditionFailed
+	default:
+		return InternalServerError(c, err)
+	}
+
+	body := map[string]any{"error": clientMessage(err)}
+	if _, name, ok := authStatus(err); ok {
+		body["code"] = name
+	} else if code == http.StatusPreconditionFailed {
+		body["code"] = "PRECONDITION_FAILED"
+	}
+	maps.Copy(body, extra)
+	return render(c, code, body)
+}
+
+// ItemError describes why item index of a batch was rejected: "fields" for
+// validation.Errors, "error" for any other errs.ErrValidation. Other errors
+// are not the client's fault and return false.
+func ItemError(index int, err error) (map[string]any, bool) {
+	var valErr *validation.Errors
+	switch {
+	case errors.As(err, &valErr):
+		return map[string]any{"index": index, "fields": valErr.Fields}, true
+	case errors.Is(err, errs.ErrValidation):
+		return map[string]any{"index": index, "error": clientMessage(err)}, true
+	}
+	return nil, false
+}
+
+// BatchValidationError reports every invalid item of a batch in one 400,
+// e.g. {"error": "Validation failed", "errors": [{"index": 2, "fields":
+// {...}}]}, with items built by ItemError.
+func BatchValidationError(c echo.Context, items []map[string]any) error {
+	return render(c, http.StatusBadRequest, map[string]any{
+		"error":  "Validation failed",
+		"errors": items,
+	})
 }
 
+// clientMessage returns the message of the *errs.Error in err's chain,
+// falling back to the bare kind for sentinels returned as is.
+func clientMessage(err error) string {
+	var e *errs.Error
+	if errors.As(err, &e) {
+		return e.Message
+	}
+	for _, kind := range []error{errs.ErrNotFound, errs.ErrConflict, errs.ErrValidation, errs.ErrForbidden, errs.ErrUnauthorized, errs.ErrPrecondition} {
+		if errors.Is(err, kind) {
+			return kind.Error()
+		}
+	}
+	return "internal error"
+}
+
+// InternalServerError logs the real error and returns a generic message, so
+// database details never reach the client. The request ID lets us match the
+// response to the log line. If the request's deadline passed, it answers
+// 504 instead, and if the database connection was lost (e.g. Postgres
+// restarted under a write that can't be retried) a 503 with Retry-After.
 func InternalServerError(c echo.Context, err error) error {
-	return c.JSON(http.StatusInternalServerError, map[string]string{
-		"error": err.Error(),
+	requestID := RequestID(c)
+	log := logger.FromContext(c.Request().Context())
+	if errors.Is(c.Request().Context().Err(), context.DeadlineExceeded) {
+		// The Timeout middleware's deadline cut the work short.
+		log.Warn("request timed out", "error", err)
+		return render(c, http.StatusGatewayTimeout, map[string]string{
+			"error":      "request timed out",
+			"request_id": requestID,
+		})
+	}
+	if !ClientGone(c) && database.ConnectionLost(err) {
+		log.Warn("database connection lost", "error", err)
+		c.Response().Header().Set("Retry-After", strconv.Itoa(int(connectionLostRetryAfter.Seconds())))
+		return render(c, http.StatusServiceUnavailable, map[string]string{
+			"error":      "Database unavailable, please retry later",
+			"request_id": requestID,
+		})
+	}
+	if ClientGone(c) {
+		// The query failed because the client hung up, not because of us.
+		log.Debug("client disconnected", "error", err)
+	} else {
+		log.Error("internal error", "error", err)
+	}
+
+	return render(c, http.StatusInternalServerError, map[string]string{
+		"error":      "internal error",
+		"request_id": requestID,
 	})
 }
 
+func ServiceUnavailable(c echo.Context, msg string, retryAfter time.Duration) error {
+	if retryAfter > 0 {
+		c.Response().Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+	}
+	return render(c, http.StatusServiceUnavailable, map[string]string{"error": msg})
+}
+
+// SetPagination reports the page actually served. The limit may be lower
+// than requested when it was clamped to the server maximum.
+func SetPagination(c echo.Context, limit, offset, total int) {
+	h := c.Response().Header()
+	h.Set("X-Pagination-Limit", strconv.Itoa(limit))
+	h.Set("X-Pagination-Offset", strconv.Itoa(offset))
+	h.Set("X-Total-Count", strconv.Itoa(total))
+	h.Set("Link", paginationLinks(c, limit, offset, total))
+}
+
+// ClientGone reports whether the client disconnected before the handler
+// finished. net/http cancels the request context when that happens, which
+// also aborts any query still running under it.
+func ClientGone(c echo.Context) bool {
+	return errors.Is(c.Request().Context().Err(), context.Canceled)
+}
+
+// authStatus maps the errs auth kinds to a status and its code name.
+func authStatus(err error) (int, string, bool) {
+	switch {
+	case errors.Is(err, errs.ErrUnauthorized):
+		return http.StatusUnauthorized, "UNAUTHORIZED", true
+	case errors.Is(err, errs.ErrForbidden):
+		return http.StatusForbidden, "FORBIDDEN", true
+	}
+	return 0, "", false
+}
+
+// RequestID returns the ID assigned by the RequestID middleware.
+func RequestID(c echo.Context) string {
+	return c.Response().Header().Get(echo.HeaderXRequestID)
+}
+
+// CustomErrorHandler renders errors no handler answered itself, as JSON
+// (or XML) by default and as a small HTML page for browsers, like any
+// other error response.
 func CustomErrorHandler(err error, c echo.Context) {
+	// The router returns ErrNotFound when no route matches the path.
+	if errors.Is(err, echo.ErrNotFound) {
+		render(c, http.StatusNotFound, map[string]string{
+			"error": "route not found",
+			"code":  "NOT_FOUND",
+		})
+		return
+	}
+
+	// Auth middleware rejections, with a machine-readable code. A 401's
+	// WWW-Authenticate header is set by the middleware, which knows the
+	// scheme.
+	if code, name, ok := authStatus(err); ok {
+		render(c, code, map[string]string{
+			"error": clientMessage(err),
+			"code":  name,
+		})
+		return
+	}
+
 	// Check if it's an echo HTTP error
 	if he, ok := err.(*echo.HTTPError); ok {
-		c.JSON(he.Code, map[string]any{
+		render(c, he.Code, map[string]any{
 			"error": he.Message,
 		})
 		return
 	}
 
 	// Default to internal server error
-	c.JSON(http.StatusInternalServerError, map[string]string{
-		"error": err.Error(),
-	})
+	InternalServerError(c, err)
 }
@@ -0,0 +1,64 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"testing"
+
+	"github.com/manish-npx/simple-go-echo/internal/errs"
+	"github.com/manish-npx/simple-go-echo/internal/validation"
+)
+
+func TestFromError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code int
+		want map[string]any
+	}{
+		{"not found", errs.NotFound("Todo not found"), http.StatusNotFound, map[string]any{"error": "Todo not found"}},
+		{"wrapped", fmt.Errorf("get todo 1: %w", errs.NotFound("Todo not found")), http.StatusNotFound, map[string]any{"error": "Todo not found"}},
+		{"bare sentinel", errs.ErrNotFound, http.StatusNotFound, map[string]any{"error": "not found"}},
+		{"conflict", errs.Conflict("Already exists"), http.StatusConflict, map[string]any{"error": "Already exists"}},
+		{"invalid", errs.Invalid("Bad bucket"), http.StatusBadRequest, map[string]any{"error": "Bad bucket"}},
+		{"forbidden", errs.Forbidden("Read-only key"), http.StatusForbidden, map[string]any{"error": "Read-only key", "code": "FORBIDDEN"}},
+		{"unauthorized", errs.Unauthorized("Missing API key"), http.StatusUnauthorized, map[string]any{"error": "Missing API key", "code": "UNAUTHORIZED"}},
+		{"precondition", errs.PreconditionFailed("Exists"), http.StatusPreconditionFailed, map[string]any{"error": "Exists", "code": "PRECONDITION_FAILED"}},
+		{
+			"validation", &validation.Errors{Fields: map[string]string{"title": "is required"}},
+			http.StatusBadRequest, map[string]any{"error": "Validation failed", "fields": map[string]any{"title": "is required"}},
+		},
+		{"unknown", errors.New("pq: password authentication failed"), http.StatusInternalServerError, map[string]any{"error": "internal error", "request_id": ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, rec := newContext("")
+			if err := FromError(c, tt.err); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != tt.code {
+				t.Errorf("status = %d, want %d", rec.Code, tt.code)
+			}
+			var body map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("body %s: %v", rec.Body, err)
+			}
+			if !maps.EqualFunc(body, tt.want, func(a, b any) bool { return fmt.Sprint(a) == fmt.Sprint(b) }) {
+				t.Errorf("body = %v, want %v", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromErrorAtAddsIndex(t *testing.T) {
+	c, rec := newContext("")
+	if err := FromErrorAt(c, errs.NotFound("Todo not found"), 2); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusNotFound || rec.Body.String() != `{"error":"Todo not found","index":2}`+"\n" {
+		t.Errorf("got %d %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,86 @@
+package response
+
+import (
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// xmlList wraps slices, which have no root element of their own. Items use
+// their own element names (e.g. <todo>), or <item> for plain maps.
+type xmlList struct {
+	XMLName xml.Name
+	Items   []any
+}
+
+// xmlMap renders a map as one child element per key, sorted for stable
+// output. encoding/xml cannot marshal maps directly.
+type xmlMap struct {
+	name   string
+	values map[string]any
+}
+
+func (m xmlMap) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name = xml.Name{Local: m.name}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(m.values))
+	for k := range m.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		el := xml.StartElement{Name: xml.Name{Local: k}}
+		if err := e.EncodeElement(toXML(m.values[k], k), el); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// toXML converts data into something encoding/xml can marshal. name is used
+// as the element name for maps and lists.
+func toXML(data any, name string) any {
+	switch v := data.(type) {
+	case map[string]any:
+		return xmlMap{name: name, values: v}
+	case map[string]string:
+		values := make(map[string]any, len(v))
+		for k, s := range v {
+			values[k] = s
+		}
+		return xmlMap{name: name, values: values}
+	case nil:
+		return ""
+	}
+
+	rv := reflect.ValueOf(data)
+	if rv.Kind() != reflect.Slice {
+		if rv.Kind() == reflect.Map {
+			return fmt.Sprint(data)
+		}
+		return data
+	}
+
+	list := xmlList{XMLName: xml.Name{Local: name}, Items: make([]any, 0, rv.Len())}
+	for i := 0; i < rv.Len(); i++ {
+		list.Items = append(list.Items, toXML(rv.Index(i).Interface(), "item"))
+	}
+	return list
+}
+
+// xmlRootName picks the root element for a response body: the resource type
+// for lists of resources, "response" otherwise.
+func xmlRootName(data any) string {
+	rv := reflect.ValueOf(data)
+	if rv.Kind() == reflect.Slice && rv.Len() > 0 {
+		if r, ok := rv.Index(0).Interface().(Resource); ok {
+			return r.ResourceType()
+		}
+	}
+	return "response"
+}
@@ -0,0 +1,128 @@
+package validation
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// jsonSchemaDialect is the JSON Schema version Schema produces.
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// Schema describes model, a struct, as a JSON Schema document built from
+// its json and validate tags, so it cannot drift from what the API
+// accepts. Pointer fields are nullable. Limits set through aliases such as
+// title_length show their configured values.
+func (cv *Validator) Schema(model any) map[string]any {
+	t := reflect.TypeOf(model)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	doc := cv.objectSchema(t)
+	doc["$schema"] = jsonSchemaDialect
+	doc["title"] = t.Name()
+	return doc
+}
+
+func (cv *Validator) objectSchema(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+
+	for _, f := range reflect.VisibleFields(t) {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || f.Anonymous || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := typeSchema(f.Type)
+		for _, rule := range cv.rules(f.Tag.Get("validate")) {
+			tag, param, _ := strings.Cut(rule, "=")
+			if tag == "required" {
+				required = append(required, name)
+				continue
+			}
+			applyRule(prop, f.Type, tag, param)
+		}
+		properties[name] = prop
+	}
+
+	return map[string]any{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// rules splits a validate tag, expanding aliases.
+func (cv *Validator) rules(tag string) []string {
+	var out []string
+	for _, rule := range strings.Split(tag, ",") {
+		if expanded, ok := cv.aliases[rule]; ok {
+			out = append(out, cv.rules(expanded)...)
+		} else if rule != "" {
+			out = append(out, rule)
+		}
+	}
+	return out
+}
+
+func typeSchema(t reflect.Type) map[string]any {
+	if t.Kind() == reflect.Pointer {
+		s := typeSchema(t.Elem())
+		s["type"] = []any{s["type"], "null"}
+		return s
+	}
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	}
+	return map[string]any{}
+}
+
+// applyRule adds the JSON Schema keyword for one validate rule. Rules with
+// no equivalent are left out.
+func applyRule(prop map[string]any, t reflect.Type, tag, param string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	n, _ := strconv.Atoi(param)
+
+	switch tag {
+	case "notblank":
+		prop["pattern"] = `\S`
+	case "max":
+		if t.Kind() == reflect.String {
+			prop["maxLength"] = n
+		} else {
+			prop["maximum"] = n
+		}
+	case "min":
+		if t.Kind() == reflect.String {
+			prop["minLength"] = n
+		} else {
+			prop["minimum"] = n
+		}
+	case "oneof":
+		prop["enum"] = strings.Fields(param)
+	}
+}
@@ -0,0 +1,136 @@
+package validation
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/manish-npx/simple-go-echo/internal/models"
+)
+
+// Errors maps each invalid JSON field to a message describing the rule it
+// broke.
+type Errors struct {
+	Fields map[string]string
+}
+
+func (e *Errors) Error() string {
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+": "+e.Fields[k])
+	}
+	return "validation failed: " + strings.Join(parts, ", ")
+}
+
+// MaxTitleLength and MaxDescriptionLength are the sizes of the title and
+// description columns, so a configured limit can only be lower.
+const (
+	MaxTitleLength       = 255
+	MaxDescriptionLength = 2000
+)
+
+// Limits are the deployment-specific length limits behind the title_length
+// and description_length tags. Zero means the default (255 and 2000).
+type Limits struct {
+	TitleMaxLength       int
+	DescriptionMaxLength int
+}
+
+// Validator implements echo.Validator using `validate` struct tags plus the
+// custom rules registered in New.
+type Validator struct {
+	v *validator.Validate
+	// aliases maps each alias tag to the rules it stands for, for Schema.
+	aliases map[string]string
+}
+
+func New(limits Limits) *Validator {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// Report fields by their JSON names, which is what clients send.
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return f.Name
+		}
+		return name
+	})
+
+	// notblank rejects strings that are empty after trimming whitespace.
+	_ = v.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+		return strings.TrimSpace(fl.Field().String()) != ""
+	})
+
+	// A patch's null or absent description is empty, so omitempty skips
+	// it; otherwise the string itself is checked.
+	v.RegisterCustomTypeFunc(func(f reflect.Value) any {
+		if s := f.Interface().(models.NullableString).Value; s != nil {
+			return *s
+		}
+		return nil
+	}, models.NullableString{})
+
+	// Aliases keep configurable limits out of the struct tags. Errors
+	// report the underlying max rule, so messages show the real limit.
+	aliases := map[string]string{
+		"title_length":       fmt.Sprintf("max=%d", cmp.Or(limits.TitleMaxLength, MaxTitleLength)),
+		"description_length": fmt.Sprintf("max=%d", cmp.Or(limits.DescriptionMaxLength, MaxDescriptionLength)),
+	}
+	for alias, tags := range aliases {
+		v.RegisterAlias(alias, tags)
+	}
+
+	return &Validator{v: v, aliases: aliases}
+}
+
+func (cv *Validator) Validate(i any) error {
+	err := cv.v.Struct(i)
+	if err == nil {
+		return nil
+	}
+
+	verrs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return err
+	}
+
+	fields := make(map[string]string, len(verrs))
+	for _, fe := range verrs {
+		fields[fe.Field()] = message(fe)
+	}
+	return &Errors{Fields: fields}
+}
+
+func message(fe validator.FieldError) string {
+	switch fe.ActualTag() {
+	case "required":
+		return "is required"
+	case "notblank":
+		return "must not be blank"
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters", fe.Param())
+		}
+		return "must be at most " + fe.Param()
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	}
+	return fmt.Sprintf("failed %q validation", fe.ActualTag())
+}
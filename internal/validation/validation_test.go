@@ -0,0 +1,99 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/manish-npx/simple-go-echo/internal/models"
+)
+
+func ptr(s string) *string { return &s }
+
+func TestValidateReportsEveryViolation(t *testing.T) {
+	v := New(Limits{})
+	todo := &models.Todo{
+		Title:       "   ",
+		Description: ptr(strings.Repeat("x", MaxDescriptionLength+1)),
+		ExternalID:  ptr(" "),
+	}
+
+	var valErr *Errors
+	if err := v.Validate(todo); !errors.As(err, &valErr) {
+		t.Fatalf("err = %v, want *Errors", err)
+	}
+	want := map[string]string{
+		"title":       "must not be blank",
+		"description": "must be at most 2000 characters",
+		"external_id": "must not be blank",
+	}
+	if len(valErr.Fields) != len(want) {
+		t.Errorf("fields = %v, want %v", valErr.Fields, want)
+	}
+	for field, msg := range want {
+		if got := valErr.Fields[field]; got != msg {
+			t.Errorf("%s: %q, want %q", field, got, msg)
+		}
+	}
+}
+
+func TestValidateAcceptsValidTodo(t *testing.T) {
+	if err := New(Limits{}).Validate(&models.Todo{Title: "Write tests"}); err != nil {
+		t.Errorf("err = %v, want nil", err)
+	}
+}
+
+func TestErrorsMessageIsSorted(t *testing.T) {
+	err := &Errors{Fields: map[string]string{"title": "is required", "done": "must be a boolean"}}
+	if got, want := err.Error(), "validation failed: done: must be a boolean, title: is required"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestValidatePatchDescription(t *testing.T) {
+	v := New(Limits{DescriptionMaxLength: 5})
+	long := "too long"
+	tests := []struct {
+		name  string
+		patch models.TodoPatch
+		want  string
+	}{
+		{"absent", models.TodoPatch{}, ""},
+		{"null", models.TodoPatch{Description: models.NullableString{Set: true}}, ""},
+		{"too long", models.TodoPatch{Description: models.NullableString{Set: true, Value: &long}}, "must be at most 5 characters"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate(&tt.patch)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("err = %v, want nil", err)
+				}
+				return
+			}
+			verrs, ok := err.(*Errors)
+			if !ok || verrs.Fields["description"] != tt.want {
+				t.Errorf("err = %v, want description: %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimitsDifferPerConfig(t *testing.T) {
+	todo := &models.Todo{Title: strings.Repeat("t", 150), Description: ptr(strings.Repeat("d", 600))}
+
+	if err := New(Limits{}).Validate(todo); err != nil {
+		t.Errorf("default limits: err = %v, want nil", err)
+	}
+
+	var valErr *Errors
+	if err := New(Limits{TitleMaxLength: 100, DescriptionMaxLength: 500}).Validate(todo); !errors.As(err, &valErr) {
+		t.Fatalf("tight limits: err = %v, want *Errors", err)
+	}
+	if got := valErr.Fields["title"]; got != "must be at most 100 characters" {
+		t.Errorf("title: %q", got)
+	}
+	if got := valErr.Fields["description"]; got != "must be at most 500 characters" {
+		t.Errorf("description: %q", got)
+	}
+}
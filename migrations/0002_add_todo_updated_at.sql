@@ -0,0 +1,10 @@
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+
+UPDATE todos SET created_at = CURRENT_TIMESTAMP WHERE created_at IS NULL;
+UPDATE todos SET updated_at = created_at WHERE updated_at IS NULL;
+
+ALTER TABLE todos ALTER COLUMN created_at SET NOT NULL;
+ALTER TABLE todos ALTER COLUMN updated_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS todos_updated_at_idx ON todos (updated_at DESC);
@@ -0,0 +1,14 @@
+-- Tell every API instance that todos changed, so they can drop cached
+-- list pages. Fires once per statement, including writes made outside
+-- the API.
+CREATE OR REPLACE FUNCTION notify_todos_changed() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('todos_changed', '');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS todos_changed ON todos;
+CREATE TRIGGER todos_changed
+    AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON todos
+    FOR EACH STATEMENT EXECUTE FUNCTION notify_todos_changed();
@@ -0,0 +1,4 @@
+-- Stable id of a todo synced from an external system. NULL for todos
+-- created through the API; the unique index backs upserts.
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS external_id TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS todos_external_id_key ON todos (external_id);
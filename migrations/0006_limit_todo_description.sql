@@ -0,0 +1,5 @@
+-- Cap descriptions at the API's 2000 characters in the database too, so a
+-- write path that skips validation can't store more. Titles have been
+-- VARCHAR(255) since 0001. Fails if a longer description already exists;
+-- shorten those first.
+ALTER TABLE todos ALTER COLUMN description TYPE VARCHAR(2000);
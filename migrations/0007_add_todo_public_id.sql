@@ -0,0 +1,4 @@
+-- Unguessable id for URLs when api.id_type is uuid. Existing rows get one
+-- too. gen_random_uuid() is built in since Postgres 13.
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS public_id UUID NOT NULL DEFAULT gen_random_uuid();
+CREATE UNIQUE INDEX IF NOT EXISTS todos_public_id_key ON todos (public_id);